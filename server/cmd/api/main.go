@@ -3,16 +3,19 @@ package main
 import (
 	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/hrutav-modha/social-media-app/server/internal/auth"
 	"github.com/hrutav-modha/social-media-app/server/internal/config"
-	customMiddleware "github.com/hrutav-modha/social-media-app/server/internal/middleware"
+	"github.com/hrutav-modha/social-media-app/server/internal/logging"
+	"github.com/hrutav-modha/social-media-app/server/internal/migrate"
+	"github.com/hrutav-modha/social-media-app/server/internal/retry"
+	"github.com/hrutav-modha/social-media-app/server/internal/server"
+	"github.com/hrutav-modha/social-media-app/server/migrations"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"github.com/minio/minio-go/v7"
@@ -32,35 +35,45 @@ func main() {
 		log.Fatalf("failed to load config: %v", err)
 	}
 
-	// 2.5 Initialize JWT
-	if err := auth.InitJWT(cfg.JWTPrivateKey, cfg.JWTPublicKey); err != nil {
-		log.Fatalf("failed to initialize JWT: %v", err)
-	}
+	// 2.1 Configure logging
+	slog.SetDefault(logging.New(logging.Config{
+		Format:       cfg.LogFormat,
+		Level:        cfg.LogLevel,
+		ModuleLevels: cfg.LogModuleLevels,
+	}))
 
-	// 3. Connect to DB (PostgreSQL)
+	// 3. Connect to DB (PostgreSQL). pgxpool connects lazily, so the ping is
+	// what actually waits for the database to come up.
 	dbPool, err := pgxpool.New(context.Background(), cfg.DBURL)
 	if err != nil {
 		log.Fatalf("failed to connect to DB: %v", err)
 	}
 	defer dbPool.Close()
 
-	if err := dbPool.Ping(context.Background()); err != nil {
+	if err := waitFor(cfg.StartupRetryTimeout, "postgres", dbPool.Ping); err != nil {
 		log.Fatalf("DB ping failed: %v", err)
 	}
 	log.Println("Successfully connected to DB")
 
+	// 3.5 Check the schema version, applying pending migrations if enabled.
+	if err := prepareSchema(context.Background(), dbPool, cfg.MigrateOnStart); err != nil {
+		log.Fatalf("database schema: %v", err)
+	}
+
 	// 4. Connect to Redis
-	rdb := redis.NewClient(&redis.Options{
-		Addr: cfg.RedisURL,
-	})
+	rdb := redis.NewClient(redisOptions(cfg.RedisURL))
 	defer rdb.Close()
 
-	if err := rdb.Ping(context.Background()).Err(); err != nil {
+	pingRedis := func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	}
+	if err := waitFor(cfg.StartupRetryTimeout, "redis", pingRedis); err != nil {
 		log.Fatalf("failed to connect to Redis: %v", err)
 	}
 	log.Println("Successfully connected to Redis")
 
-	// 5. Connect to MinIO
+	// 5. Connect to MinIO. It only backs media uploads, so the server starts
+	// without it and disables those routes until it shows up.
 	minioClient, err := minio.New(cfg.MinioEndpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(cfg.MinioAccessKey, cfg.MinioSecretKey, ""),
 		Secure: false, // Set to true if using TLS
@@ -68,16 +81,48 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to connect to MinIO: %v", err)
 	}
-	_ = minioClient // For now, use to avoid unused variable error
-	log.Println("Successfully connected to MinIO")
+	if err := waitFor(cfg.OptionalStartupTimeout, "minio", server.CheckMinio(minioClient)); err != nil {
+		slog.Warn("MinIO unavailable, starting in degraded mode with media routes disabled",
+			slog.Any("error", err))
+	} else {
+		log.Println("Successfully connected to MinIO")
+	}
+
+	// 6. Build the app, which owns the token issuer, session store and
+	// readiness checks, and register routes
+	app, err := server.New(cfg, server.Deps{DB: dbPool, Redis: rdb, Minio: minioClient})
+	if err != nil {
+		log.Fatal(err)
+	}
+	r := app.Router()
+
+	// Background jobs stop when the server starts shutting down.
+	jobs, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if cfg.SuggestionsRefreshInterval > 0 {
+		go app.Suggestions.Run(jobs, cfg.SuggestionsRefreshInterval)
+	}
+	if cfg.AccountPurgeInterval > 0 {
+		go app.Accounts.Run(jobs, cfg.AccountPurgeInterval)
+	}
 
-	// 6. Register Routes
-	r := SetupRouter(cfg, rdb)
+	// Reload the settings that can change at runtime on SIGHUP.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reloadConfig(app.CORS)
+		}
+	}()
 
 	// 7. Start Server with Graceful Shutdown
 	srv := &http.Server{
-		Addr:    ":" + cfg.Port,
-		Handler: r,
+		Addr:              ":" + cfg.Port,
+		Handler:           r,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
 	}
 
 	go func() {
@@ -92,6 +137,12 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	stopJobs()
+
+	// Fail readiness first and give load balancers a moment to notice
+	// before we stop accepting connections.
+	app.Checker.SetDraining()
+	time.Sleep(cfg.ShutdownDrainDelay)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -102,29 +153,75 @@ func main() {
 	log.Println("Server exiting")
 }
 
-func SetupRouter(cfg *config.Config, rdb *redis.Client) *chi.Mux {
-	r := chi.NewRouter()
-	r.Use(customMiddleware.RequestID)
-	r.Use(customMiddleware.Logger)
-	r.Use(customMiddleware.Recoverer)
-	r.Use(customMiddleware.CORS(cfg.CORSAllowedOrigins))
-
-	authHandler := auth.NewAuthHandler(rdb)
+// waitFor retries check with exponential backoff until it succeeds or timeout
+// elapses, logging each failed attempt.
+func waitFor(timeout time.Duration, name string, check func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	r.Route("/api/v1", func(r chi.Router) {
-		r.Route("/auth", func(r chi.Router) {
-			r.Post("/refresh", authHandler.Refresh)
-			r.With(customMiddleware.Auth).Post("/logout", authHandler.Logout)
-		})
+	return retry.Do(ctx, retry.Backoff{MaxElapsed: timeout}, check, func(attempt int, delay time.Duration, err error) {
+		slog.Warn("dependency not ready, retrying",
+			slog.String("dependency", name),
+			slog.Int("attempt", attempt),
+			slog.Duration("retry_in", delay),
+			slog.Any("error", err),
+		)
 	})
+}
 
-	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("Social Media App API is running!"))
-	})
+// prepareSchema refuses to run against a dirty database or one migrated by a
+// newer release, and applies pending migrations when migrateOnStart is set.
+// Replicas booting together serialise on the migrator's advisory lock.
+func prepareSchema(ctx context.Context, pool *pgxpool.Pool, migrateOnStart bool) error {
+	m, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		return err
+	}
 
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
-	return r
+	if migrateOnStart {
+		applied, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if applied > 0 {
+			slog.Info("applied database migrations", slog.Int("count", applied), slog.Uint64("version", uint64(m.Latest())))
+		}
+	}
+
+	pending, err := m.Check(ctx)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		slog.Warn("database has pending migrations, run `smctl migrate up` or set MIGRATE_ON_START",
+			slog.Int("pending", pending))
+	}
+	return nil
+}
+
+// redisOptions accepts either a redis:// URL or a bare host:port.
+func redisOptions(redisURL string) *redis.Options {
+	if opts, err := redis.ParseURL(redisURL); err == nil {
+		return opts
+	}
+	return &redis.Options{Addr: redisURL}
+}
+
+// reloadConfig re-reads the configuration and applies the settings that can
+// change at runtime: CORS origins and log levels. Everything else still needs
+// a restart. An invalid configuration is logged and the current one kept.
+func reloadConfig(cors *server.CORSPolicies) {
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("config reload failed, keeping current settings", slog.Any("error", err))
+		return
+	}
+
+	logging.SetLevels(slog.Default(), cfg.LogLevel, cfg.LogModuleLevels)
+	cors.Update(cfg)
+	slog.Info("configuration reloaded",
+		slog.String("log_level", cfg.LogLevel.String()),
+		slog.Any("cors_allowed_origins", cfg.CORSAllowedOrigins),
+		slog.Any("cors_public_origins", cfg.CORSPublicOrigins),
+	)
 }
@@ -1,43 +0,0 @@
-package main
-
-import (
-	"net/http"
-	"net/http/httptest"
-	"testing"
-
-	"github.com/hrutav-modha/social-media-app/server/internal/config"
-	"github.com/stretchr/testify/assert"
-)
-
-func TestSetupRouter(t *testing.T) {
-	cfg := &config.Config{
-		CORSAllowedOrigins: "*",
-	}
-	router := SetupRouter(cfg, nil)
-
-	t.Run("Root endpoint returns 200", func(t *testing.T) {
-		req, _ := http.NewRequest("GET", "/", nil)
-		w := httptest.NewRecorder()
-		router.ServeHTTP(w, req)
-
-		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, "Social Media App API is running!", w.Body.String())
-	})
-
-	t.Run("Health check returns 200", func(t *testing.T) {
-		req, _ := http.NewRequest("GET", "/health", nil)
-		w := httptest.NewRecorder()
-		router.ServeHTTP(w, req)
-
-		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, "OK", w.Body.String())
-	})
-
-	t.Run("RequestID header is present in response", func(t *testing.T) {
-		req, _ := http.NewRequest("GET", "/health", nil)
-		w := httptest.NewRecorder()
-		router.ServeHTTP(w, req)
-
-		assert.NotEmpty(t, w.Header().Get("X-Request-ID"))
-	})
-}
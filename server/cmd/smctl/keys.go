@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/auth"
+)
+
+const (
+	privateKeyFile = "jwt_private.pem"
+	publicKeyFile  = "jwt_public.pem"
+)
+
+func runKeys(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 || args[0] != "generate" {
+		return usageError("keys needs generate")
+	}
+
+	fset := newFlagSet(e, "keys generate")
+	alg := fset.String("alg", auth.KeyRSA, "key algorithm: rsa or ed25519")
+	bits := fset.Int("bits", 3072, "RSA key size in bits")
+	out := fset.String("out", "", "directory to write "+privateKeyFile+" and "+publicKeyFile+" to; prints to stdout if empty")
+	force := fset.Bool("force", false, "overwrite existing key files")
+	if err := fset.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	privPEM, pubPEM, err := auth.GenerateKeyPair(*alg, *bits)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		fmt.Fprint(e.stdout, privPEM, pubPEM)
+		return nil
+	}
+
+	privPath := filepath.Join(*out, privateKeyFile)
+	pubPath := filepath.Join(*out, publicKeyFile)
+	if !*force {
+		for _, path := range []string{privPath, pubPath} {
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("%s already exists, use -force to overwrite", path)
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	if err := os.MkdirAll(*out, 0o700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(privPath, []byte(privPEM), 0o600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(pubPath, []byte(pubPEM), 0o644); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.stdout, "wrote %s and %s\nset JWT_PRIVATE_KEY_FILE=%s and JWT_PUBLIC_KEY_FILE=%s\n",
+		privPath, pubPath, privPath, pubPath)
+	return nil
+}
@@ -0,0 +1,178 @@
+// Command smctl is the admin CLI for the server. It reads the same
+// configuration as cmd/api.
+//
+//	smctl migrate up|down|status     apply, revert or list migrations
+//	smctl keys generate              create a JWT signing key pair
+//	smctl users create|promote       manage accounts
+//	smctl sessions revoke USERNAME   sign a user out everywhere
+//	smctl config print               show the effective config, secrets masked
+//	smctl seed                       fill the database with generated data
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
+)
+
+// command is a top-level smctl subcommand.
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, env *env, args []string) error
+}
+
+var commands = []command{
+	{"migrate", "migrate up | down [-steps N] | status", runMigrate},
+	{"keys", "keys generate [-alg rsa|ed25519] [-bits N] [-out DIR] [-force]", runKeys},
+	{"users", "users create -username NAME -email EMAIL [-display-name NAME] [-admin] < password\n" +
+		"  users promote [-role admin|user] USERNAME", runUsers},
+	{"sessions", "sessions revoke USERNAME", runSessions},
+	{"config", "config print", runConfig},
+	{"seed", "seed [-users N] [-seed N] [-reset] [-password PASSWORD] ...", runSeed},
+}
+
+// env carries the process's streams and lazily opened connections, so that
+// commands like `keys generate` work without any configuration.
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	cfg *config.Config
+	db  *pgxpool.Pool
+	rdb *redis.Client
+}
+
+func (e *env) config() (*config.Config, error) {
+	if e.cfg != nil {
+		return e.cfg, nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	e.cfg = cfg
+	return cfg, nil
+}
+
+func (e *env) database(ctx context.Context) (*pgxpool.Pool, error) {
+	if e.db != nil {
+		return e.db, nil
+	}
+	cfg, err := e.config()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := pgxpool.New(ctx, cfg.DBURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to DB: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("DB ping failed: %w", err)
+	}
+	e.db = pool
+	return pool, nil
+}
+
+func (e *env) redis(ctx context.Context) (*redis.Client, error) {
+	if e.rdb != nil {
+		return e.rdb, nil
+	}
+	cfg, err := e.config()
+	if err != nil {
+		return nil, err
+	}
+	opts, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		opts = &redis.Options{Addr: cfg.RedisURL}
+	}
+	rdb := redis.NewClient(opts)
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		rdb.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	e.rdb = rdb
+	return rdb, nil
+}
+
+func (e *env) close() {
+	if e.db != nil {
+		e.db.Close()
+	}
+	if e.rdb != nil {
+		e.rdb.Close()
+	}
+}
+
+// usageError makes main print the usage text after the message.
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+func main() {
+	_ = godotenv.Load()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	e := &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	err := run(ctx, e, os.Args[1:])
+	e.close()
+
+	var uerr usageError
+	switch {
+	case errors.As(err, &uerr):
+		if uerr != "" {
+			fmt.Fprintln(os.Stderr, "smctl:", uerr)
+		}
+		usage(os.Stderr)
+		os.Exit(2)
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(0)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "smctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		return usageError("")
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(ctx, e, args[1:])
+		}
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(e.stdout)
+		return nil
+	}
+	return usageError(fmt.Sprintf("unknown command %q", args[0]))
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	for _, cmd := range commands {
+		fmt.Fprintln(w, "  smctl", cmd.usage)
+	}
+}
+
+// newFlagSet returns a flag set that reports errors instead of exiting.
+func newFlagSet(e *env, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("smctl "+name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
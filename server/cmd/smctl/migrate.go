@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/migrate"
+	"github.com/hrutav-modha/social-media-app/server/migrations"
+)
+
+func runMigrate(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		return usageError("migrate needs up, down or status")
+	}
+
+	fs := newFlagSet(e, "migrate "+args[0])
+	steps := 1
+	if args[0] == "down" {
+		fs.IntVar(&steps, "steps", 1, "number of migrations to revert")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError(fmt.Sprintf("unexpected argument %q", fs.Arg(0)))
+	}
+
+	pool, err := e.database(ctx)
+	if err != nil {
+		return err
+	}
+	m, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		version, _, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(e.stdout, "applied %d migration(s), now at version %d\n", applied, version)
+	case "down":
+		if steps < 1 {
+			return usageError("-steps must be at least 1")
+		}
+		reverted, err := m.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		version, _, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(e.stdout, "reverted %d migration(s), now at version %d\n", reverted, version)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		version, dirty, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(tw, "%06d\t%s\t%s\n", s.Version, s.Name, state)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		if dirty {
+			fmt.Fprintf(e.stdout, "database is DIRTY at version %d\n", version)
+		}
+	default:
+		return usageError(fmt.Sprintf("unknown migrate command %q", args[0]))
+	}
+	return nil
+}
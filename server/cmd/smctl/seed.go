@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/auth"
+	"github.com/hrutav-modha/social-media-app/server/internal/seed"
+)
+
+// defaultSeedPassword is the password every seeded account gets, so any of
+// them can be logged in to during development.
+const defaultSeedPassword = "password123"
+
+// runSeed fills the database with generated users, follows, posts, likes,
+// comments and notifications.
+func runSeed(ctx context.Context, e *env, args []string) error {
+	def := seed.DefaultConfig()
+
+	fs := newFlagSet(e, "seed")
+	cfg := def
+	fs.Uint64Var(&cfg.Seed, "seed", def.Seed, "random seed; the same flags always produce the same data")
+	fs.IntVar(&cfg.Users, "users", def.Users, "number of users")
+	fs.Float64Var(&cfg.AvgFollowing, "avg-following", def.AvgFollowing, "mean number of accounts each user follows")
+	fs.Float64Var(&cfg.FollowSkew, "skew", def.FollowSkew, "Zipf exponent (> 1) for follower concentration")
+	fs.Float64Var(&cfg.AvgPosts, "avg-posts", def.AvgPosts, "mean number of posts per user")
+	fs.Float64Var(&cfg.LikeRate, "like-rate", def.LikeRate, "fraction of an author's followers that like each post")
+	fs.Float64Var(&cfg.AvgComments, "avg-comments", def.AvgComments, "mean number of comments per post")
+	fs.Float64Var(&cfg.ReplyRate, "reply-rate", def.ReplyRate, "probability that a comment is a reply")
+	fs.DurationVar(&cfg.Span, "span", def.Span, "how far back activity goes")
+	until := fs.String("until", def.Until.Format(time.DateOnly), "date of the newest activity, YYYY-MM-DD")
+	password := fs.String("password", defaultSeedPassword, "password for every seeded user")
+	reset := fs.Bool("reset", false, "delete all existing users and their content first")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError("seed takes no arguments")
+	}
+
+	var err error
+	if cfg.Until, err = time.Parse(time.DateOnly, *until); err != nil {
+		return usageError("-until must be a date such as 2024-06-01")
+	}
+	if err := cfg.Validate(); err != nil {
+		return usageError(err.Error())
+	}
+
+	start := time.Now()
+	ds, err := seed.Generate(cfg)
+	if err != nil {
+		return err
+	}
+	// Hash once: bcrypt per row would dominate the run time.
+	hash, err := auth.HashPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	pool, err := e.database(ctx)
+	if err != nil {
+		return err
+	}
+	counts, err := seed.Load(ctx, pool, ds, hash, *reset)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.stdout, "seeded %d users, %d follows, %d posts, %d likes, %d comments, %d notifications in %s\n",
+		counts.Users, counts.Follows, counts.Posts, counts.Likes, counts.Comments, counts.Notifications,
+		time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/auth"
+	"github.com/jackc/pgx/v5"
+)
+
+func runSessions(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 || args[0] != "revoke" {
+		return usageError("sessions needs revoke")
+	}
+	if len(args) != 2 {
+		return usageError("sessions revoke needs exactly one USERNAME")
+	}
+	username := args[1]
+
+	pool, err := e.database(ctx)
+	if err != nil {
+		return err
+	}
+	var userID string
+	err = pool.QueryRow(ctx, `SELECT id FROM users WHERE username = $1`, username).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("no user named %q", username)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	rdb, err := e.redis(ctx)
+	if err != nil {
+		return err
+	}
+	n, err := auth.NewSessionStore(rdb, auth.SystemClock, rand.Reader).RevokeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.stdout, "revoked %d session(s) for %s; access tokens already issued stay valid until they expire\n", n, username)
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func runConfig(ctx context.Context, e *env, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return usageError("config needs print")
+	}
+
+	cfg, err := e.config()
+	if err != nil {
+		return err
+	}
+	for _, s := range cfg.Settings() {
+		value := s.Value
+		if strings.ContainsAny(value, " \t\n\"'#") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(e.stdout, "%s=%s\n", s.Key, value)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/auth"
+	"github.com/hrutav-modha/social-media-app/server/internal/users"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	roleUser  = "user"
+	roleAdmin = "admin"
+
+	minPasswordLength = 8
+)
+
+func runUsers(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		return usageError("users needs create or promote")
+	}
+	switch args[0] {
+	case "create":
+		return createUser(ctx, e, args[1:])
+	case "promote":
+		return promoteUser(ctx, e, args[1:])
+	default:
+		return usageError(fmt.Sprintf("unknown users command %q", args[0]))
+	}
+}
+
+// createUser inserts an account. The password is read from the first line of
+// standard input so it never shows up in shell history or the process list.
+func createUser(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "users create")
+	username := fs.String("username", "", "username, 3-20 letters or digits")
+	email := fs.String("email", "", "email address")
+	displayName := fs.String("display-name", "", "display name; defaults to the username")
+	admin := fs.Bool("admin", false, "create the user as an admin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Admin-created accounts follow the same naming rules as renames.
+	name, err := users.ValidateUsername(*username)
+	if err != nil {
+		return usageError("-username: " + err.Error())
+	}
+	*username = name
+	addr, err := mail.ParseAddress(*email)
+	if err != nil || addr.Address != *email {
+		return usageError("-email must be a valid email address")
+	}
+	if *displayName == "" {
+		*displayName = *username
+	}
+
+	fmt.Fprintln(e.stderr, "Password (read from stdin):")
+	password, err := bufio.NewReader(e.stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+	password = strings.TrimRight(password, "\r\n")
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	role := roleUser
+	if *admin {
+		role = roleAdmin
+	}
+
+	pool, err := e.database(ctx)
+	if err != nil {
+		return err
+	}
+
+	var id string
+	err = pool.QueryRow(ctx,
+		`INSERT INTO users (username, email, password_hash, display_name, role)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id`,
+		*username, strings.ToLower(*email), hash, *displayName, role,
+	).Scan(&id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return fmt.Errorf("username or email already taken")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	fmt.Fprintf(e.stdout, "created %s %s (%s)\n", role, *username, id)
+	return nil
+}
+
+func promoteUser(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "users promote")
+	role := fs.String("role", roleAdmin, "role to grant: admin or user")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError("users promote needs exactly one USERNAME")
+	}
+	if *role != roleAdmin && *role != roleUser {
+		return usageError("-role must be admin or user")
+	}
+
+	pool, err := e.database(ctx)
+	if err != nil {
+		return err
+	}
+
+	tag, err := pool.Exec(ctx,
+		`UPDATE users SET role = $2, updated_at = NOW() WHERE username = $1`,
+		fs.Arg(0), *role,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("no user named %q", fs.Arg(0))
+	}
+
+	fmt.Fprintf(e.stdout, "%s is now %s\n", fs.Arg(0), *role)
+	return nil
+}
@@ -0,0 +1,16 @@
+package auth
+
+import "time"
+
+// Clock tells the time. Everything in the package that stamps or checks a
+// time goes through one, so tests can control expiry.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock is the real wall clock.
+var SystemClock Clock = systemClock{}
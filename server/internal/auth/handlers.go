@@ -2,18 +2,20 @@ package auth
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"time"
 
-	"github.com/redis/go-redis/v9"
+	"github.com/hrutav-modha/social-media-app/server/internal/logging"
 )
 
 type AuthHandler struct {
-	rdb *redis.Client
+	tokens   *TokenIssuer
+	sessions *SessionStore
 }
 
-func NewAuthHandler(rdb *redis.Client) *AuthHandler {
-	return &AuthHandler{rdb: rdb}
+func NewAuthHandler(tokens *TokenIssuer, sessions *SessionStore) *AuthHandler {
+	return &AuthHandler{tokens: tokens, sessions: sessions}
 }
 
 // Refresh handles POST /api/v1/auth/refresh
@@ -25,13 +27,13 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	}
 
 	oldToken := cookie.Value
-	newToken, userID, err := RotateRefreshToken(r.Context(), h.rdb, oldToken)
+	newToken, userID, err := h.sessions.Rotate(r.Context(), oldToken)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	accessToken, err := GenerateAccessToken(userID)
+	accessToken, err := h.tokens.GenerateAccessToken(userID)
 	if err != nil {
 		http.Error(w, "failed to generate access token", http.StatusInternalServerError)
 		return
@@ -42,7 +44,7 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 		Name:     "refresh_token",
 		Value:    newToken,
 		Path:     "/api/v1/auth",
-		Expires:  time.Now().Add(RefreshTokenTTL),
+		Expires:  h.sessions.clock.Now().Add(RefreshTokenTTL),
 		HttpOnly: true,
 		Secure:   true, // Should be true in production
 		SameSite: http.SameSiteStrictMode,
@@ -59,9 +61,11 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie("refresh_token")
 	if err == nil {
 		// If cookie exists, delete it from Redis
-		err = DeleteRefreshToken(r.Context(), h.rdb, cookie.Value)
+		err = h.sessions.Delete(r.Context(), cookie.Value)
 		if err != nil {
 			// Log error but continue to clear cookie
+			logging.FromContext(r.Context()).With(logging.ModuleKey, "auth").
+				Warn("failed to delete refresh token", slog.Any("error", err))
 		}
 	}
 
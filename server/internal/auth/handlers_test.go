@@ -2,54 +2,37 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 	"time"
 
-	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestRefreshHandler(t *testing.T) {
 	// 1. Setup Redis
-	redisURL := os.Getenv("REDIS_URL")
-	if redisURL == "" {
-		redisURL = "redis://localhost:6379"
-	}
-
-	opt, err := redis.ParseURL(redisURL)
-	if err != nil {
-		opt = &redis.Options{
-			Addr: "localhost:6379",
-		}
-	}
-
-	rdb := redis.NewClient(opt)
+	rdb := newTestRedis(t)
 	ctx := context.Background()
 
-	if err := rdb.Ping(ctx).Err(); err != nil {
-		t.Skip("Redis not available, skipping test")
-	}
-	defer rdb.Close()
-
 	// 2. Setup JWT
 	priv, pub, err := generateTestKeys()
 	require.NoError(t, err)
 
-	err = InitJWT(priv, pub)
+	issuer, err := NewTokenIssuer(priv, pub, SystemClock)
 	require.NoError(t, err)
 
-	handler := NewAuthHandler(rdb)
+	sessions := NewSessionStore(rdb, SystemClock, rand.Reader)
+	handler := NewAuthHandler(issuer, sessions)
 
 	userID := "test-user-id"
 
 	t.Run("Successful Refresh", func(t *testing.T) {
 		// Create a refresh token first
-		token, err := CreateRefreshToken(ctx, rdb, userID)
+		token, err := sessions.Create(ctx, userID)
 		require.NoError(t, err)
 
 		req := httptest.NewRequest("POST", "/api/v1/auth/refresh", nil)
@@ -82,6 +65,23 @@ func TestRefreshHandler(t *testing.T) {
 		assert.True(t, found)
 	})
 
+	t.Run("Cookie expiry follows the clock", func(t *testing.T) {
+		now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		h := NewAuthHandler(issuer, NewSessionStore(rdb, &fakeClock{now: now}, rand.Reader))
+		token, err := h.sessions.Create(ctx, userID)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/api/v1/auth/refresh", nil)
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: token})
+		w := httptest.NewRecorder()
+		h.Refresh(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.True(t, cookies[0].Expires.Equal(now.Add(RefreshTokenTTL)))
+	})
+
 	t.Run("Missing Cookie", func(t *testing.T) {
 		req := httptest.NewRequest("POST", "/api/v1/auth/refresh", nil)
 		w := httptest.NewRecorder()
@@ -107,32 +107,15 @@ func TestRefreshHandler(t *testing.T) {
 }
 
 func TestAuthHandler_Logout(t *testing.T) {
-	redisURL := os.Getenv("REDIS_URL")
-	if redisURL == "" {
-		redisURL = "redis://localhost:6379"
-	}
-
-	opt, err := redis.ParseURL(redisURL)
-	if err != nil {
-		opt = &redis.Options{
-			Addr: "localhost:6379",
-		}
-	}
-
-	rdb := redis.NewClient(opt)
+	rdb := newTestRedis(t)
 	ctx := context.Background()
 
-	// Check if redis is available
-	if err := rdb.Ping(ctx).Err(); err != nil {
-		t.Skip("Redis not available, skipping test")
-	}
-	defer rdb.Close()
-
-	h := NewAuthHandler(rdb)
+	sessions := NewSessionStore(rdb, SystemClock, rand.Reader)
+	h := NewAuthHandler(nil, sessions)
 
 	t.Run("Logout clears cookie and deletes token from Redis", func(t *testing.T) {
 		userID := "test-user-id"
-		token, err := CreateRefreshToken(ctx, rdb, userID)
+		token, err := sessions.Create(ctx, userID)
 		require.NoError(t, err)
 
 		// Create request with refresh_token cookie
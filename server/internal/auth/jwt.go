@@ -1,32 +1,54 @@
 package auth
 
 import (
-	"crypto/rsa"
+	"crypto"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var (
-	privateKey *rsa.PrivateKey
-	publicKey  *rsa.PublicKey
-)
+// AccessTokenTTL is how long an access token is valid for.
+const AccessTokenTTL = 15 * time.Minute
 
-// InitJWT initializes the RSA keys for signing and validating JWTs.
-func InitJWT(privateKeyPEM, publicKeyPEM string) error {
-	var err error
-	privateKey, err = jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyPEM))
-	if err != nil {
-		return fmt.Errorf("failed to parse RSA private key: %v", err)
+// TokenIssuer signs and validates access tokens with one key pair.
+type TokenIssuer struct {
+	privateKey    crypto.PrivateKey
+	publicKey     crypto.PublicKey
+	signingMethod jwt.SigningMethod
+	clock         Clock
+}
+
+// NewTokenIssuer parses the key pair. Both keys must be RSA (RS256) or both
+// Ed25519 (EdDSA), and the public key must belong to the private one.
+// Tokens are stamped and checked against clock.
+func NewTokenIssuer(privateKeyPEM, publicKeyPEM string, clock Clock) (*TokenIssuer, error) {
+	var issuer *TokenIssuer
+	if priv, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyPEM)); err == nil {
+		pub, err := jwt.ParseRSAPublicKeyFromPEM([]byte(publicKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RSA public key: %v", err)
+		}
+		issuer = &TokenIssuer{privateKey: priv, publicKey: pub, signingMethod: jwt.SigningMethodRS256, clock: clock}
+	} else if priv, err := jwt.ParseEdPrivateKeyFromPEM([]byte(privateKeyPEM)); err == nil {
+		pub, err := jwt.ParseEdPublicKeyFromPEM([]byte(publicKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Ed25519 public key: %v", err)
+		}
+		issuer = &TokenIssuer{privateKey: priv, publicKey: pub, signingMethod: jwt.SigningMethodEdDSA, clock: clock}
+	} else {
+		return nil, fmt.Errorf("failed to parse private key: not an RSA or Ed25519 PEM key")
 	}
 
-	publicKey, err = jwt.ParseRSAPublicKeyFromPEM([]byte(publicKeyPEM))
+	// A mismatched pair would otherwise start fine and reject every token.
+	probe, err := issuer.GenerateAccessToken("key-check")
 	if err != nil {
-		return fmt.Errorf("failed to parse RSA public key: %v", err)
+		return nil, fmt.Errorf("failed to sign with private key: %v", err)
 	}
-
-	return nil
+	if _, err := issuer.ValidateAccessToken(probe); err != nil {
+		return nil, fmt.Errorf("public key does not match private key: %v", err)
+	}
+	return issuer, nil
 }
 
 // Claims defines the JWT claims.
@@ -35,39 +57,31 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-// GenerateAccessToken generates a new RS256 signed JWT for a user.
-func GenerateAccessToken(userID string) (string, error) {
-	if privateKey == nil {
-		return "", fmt.Errorf("JWT private key not initialized")
-	}
-
-	expirationTime := time.Now().Add(15 * time.Minute)
+// GenerateAccessToken generates a new signed JWT for a user.
+func (i *TokenIssuer) GenerateAccessToken(userID string) (string, error) {
+	now := i.clock.Now()
 	claims := &Claims{
 		UserID: userID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "social-media-app",
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	return token.SignedString(privateKey)
+	token := jwt.NewWithClaims(i.signingMethod, claims)
+	return token.SignedString(i.privateKey)
 }
 
 // ValidateAccessToken validates the JWT token and returns the userID.
-func ValidateAccessToken(tokenString string) (string, error) {
-	if publicKey == nil {
-		return "", fmt.Errorf("JWT public key not initialized")
-	}
-
+func (i *TokenIssuer) ValidateAccessToken(tokenString string) (string, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		if token.Method.Alg() != i.signingMethod.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return publicKey, nil
-	})
+		return i.publicKey, nil
+	}, jwt.WithTimeFunc(i.clock.Now))
 
 	if err != nil {
 		return "", err
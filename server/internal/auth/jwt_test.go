@@ -5,8 +5,11 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -39,30 +42,84 @@ func TestJWT(t *testing.T) {
 	priv, pub, err := generateTestKeys()
 	require.NoError(t, err)
 
-	err = InitJWT(priv, pub)
+	issuer, err := NewTokenIssuer(priv, pub, SystemClock)
 	require.NoError(t, err)
 
 	userID := "test-user-id"
 
 	t.Run("GenerateAccessToken", func(t *testing.T) {
-		token, err := GenerateAccessToken(userID)
+		token, err := issuer.GenerateAccessToken(userID)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, token)
 
 		// Validate it back
-		gotUserID, err := ValidateAccessToken(token)
+		gotUserID, err := issuer.ValidateAccessToken(token)
 		assert.NoError(t, err)
 		assert.Equal(t, userID, gotUserID)
 	})
 
 	t.Run("InvalidToken", func(t *testing.T) {
-		_, err := ValidateAccessToken("invalid.token.here")
+		_, err := issuer.ValidateAccessToken("invalid.token.here")
 		assert.Error(t, err)
 	})
 
 	t.Run("ExpiredToken", func(t *testing.T) {
-		// Mocking time for expiration is harder without a clock provider, 
-		// but we can manually create an expired token for testing if needed.
-		// For now, let's just test basic functionality.
+		clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+		issuer, err := NewTokenIssuer(priv, pub, clock)
+		require.NoError(t, err)
+
+		token, err := issuer.GenerateAccessToken(userID)
+		require.NoError(t, err)
+
+		clock.Advance(AccessTokenTTL - time.Second)
+		_, err = issuer.ValidateAccessToken(token)
+		assert.NoError(t, err)
+
+		clock.Advance(2 * time.Second)
+		_, err = issuer.ValidateAccessToken(token)
+		assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+	})
+
+	t.Run("Issuers are independent", func(t *testing.T) {
+		otherPriv, otherPub, err := GenerateKeyPair(KeyEd25519, 0)
+		require.NoError(t, err)
+		other, err := NewTokenIssuer(otherPriv, otherPub, SystemClock)
+		require.NoError(t, err)
+
+		token, err := other.GenerateAccessToken(userID)
+		require.NoError(t, err)
+		_, err = issuer.ValidateAccessToken(token)
+		assert.Error(t, err)
 	})
+
+	t.Run("NotYetValidToken", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+		issuer, err := NewTokenIssuer(priv, pub, clock)
+		require.NoError(t, err)
+
+		token, err := issuer.GenerateAccessToken(userID)
+		require.NoError(t, err)
+
+		clock.Advance(-time.Hour)
+		_, err = issuer.ValidateAccessToken(token)
+		assert.Error(t, err)
+	})
+}
+
+// fakeClock is a Clock that only moves when told to.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
 }
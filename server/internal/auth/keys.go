@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// Key algorithms accepted by GenerateKeyPair.
+const (
+	KeyRSA     = "rsa"
+	KeyEd25519 = "ed25519"
+)
+
+// MinRSAKeyBits is the smallest RSA key GenerateKeyPair will create.
+const MinRSAKeyBits = 2048
+
+// GenerateKeyPair creates a signing key pair for NewTokenIssuer and returns it PEM
+// encoded: RSA private keys as PKCS #1, Ed25519 private keys as PKCS #8 and
+// public keys as PKIX. rsaBits is ignored for Ed25519.
+func GenerateKeyPair(algorithm string, rsaBits int) (privatePEM, publicPEM string, err error) {
+	var priv crypto.PrivateKey
+	var pub crypto.PublicKey
+	var privBlock *pem.Block
+
+	switch algorithm {
+	case KeyRSA:
+		if rsaBits < MinRSAKeyBits {
+			return "", "", fmt.Errorf("RSA keys must be at least %d bits", MinRSAKeyBits)
+		}
+		key, err := rsa.GenerateKey(rand.Reader, rsaBits)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to generate RSA key: %w", err)
+		}
+		priv, pub = key, &key.PublicKey
+		privBlock = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	case KeyEd25519:
+		edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to generate Ed25519 key: %w", err)
+		}
+		priv, pub = edPriv, edPub
+	default:
+		return "", "", fmt.Errorf("unknown key algorithm %q, want %s or %s", algorithm, KeyRSA, KeyEd25519)
+	}
+
+	if privBlock == nil {
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to marshal private key: %w", err)
+		}
+		privBlock = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal public key: %w", err)
+	}
+
+	return string(pem.EncodeToMemory(privBlock)),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})), nil
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateKeyPair(t *testing.T) {
+	for _, alg := range []string{KeyRSA, KeyEd25519} {
+		t.Run(alg, func(t *testing.T) {
+			priv, pub, err := GenerateKeyPair(alg, MinRSAKeyBits)
+			require.NoError(t, err)
+			issuer, err := NewTokenIssuer(priv, pub, SystemClock)
+			require.NoError(t, err)
+
+			token, err := issuer.GenerateAccessToken("test-user-id")
+			require.NoError(t, err)
+
+			userID, err := issuer.ValidateAccessToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, "test-user-id", userID)
+		})
+	}
+
+	t.Run("Tokens from the other algorithm are rejected", func(t *testing.T) {
+		priv, pub, err := GenerateKeyPair(KeyEd25519, 0)
+		require.NoError(t, err)
+		ed, err := NewTokenIssuer(priv, pub, SystemClock)
+		require.NoError(t, err)
+		token, err := ed.GenerateAccessToken("test-user-id")
+		require.NoError(t, err)
+
+		priv, pub, err = GenerateKeyPair(KeyRSA, MinRSAKeyBits)
+		require.NoError(t, err)
+		rsa, err := NewTokenIssuer(priv, pub, SystemClock)
+		require.NoError(t, err)
+
+		_, err = rsa.ValidateAccessToken(token)
+		assert.Error(t, err)
+	})
+
+	t.Run("Weak or unknown keys", func(t *testing.T) {
+		_, _, err := GenerateKeyPair(KeyRSA, 1024)
+		assert.Error(t, err)
+		_, _, err = GenerateKeyPair("dsa", 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("Mismatched key types", func(t *testing.T) {
+		priv, _, err := GenerateKeyPair(KeyEd25519, 0)
+		require.NoError(t, err)
+		_, pub, err := GenerateKeyPair(KeyRSA, MinRSAKeyBits)
+		require.NoError(t, err)
+		_, err = NewTokenIssuer(priv, pub, SystemClock)
+		assert.Error(t, err)
+	})
+	t.Run("Mismatched key pairs", func(t *testing.T) {
+		for _, alg := range []string{KeyRSA, KeyEd25519} {
+			priv, _, err := GenerateKeyPair(alg, MinRSAKeyBits)
+			require.NoError(t, err)
+			_, pub, err := GenerateKeyPair(alg, MinRSAKeyBits)
+			require.NoError(t, err)
+			_, err = NewTokenIssuer(priv, pub, SystemClock)
+			assert.ErrorContains(t, err, "does not match", alg)
+		}
+	})
+}
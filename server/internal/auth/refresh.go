@@ -2,19 +2,20 @@ package auth
 
 import (
 	"context"
-	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
 const (
-	RefreshTokenTTL = 30 * 24 * time.Hour
-	SessionPrefix   = "session:"
+	RefreshTokenTTL    = 30 * 24 * time.Hour
+	SessionPrefix      = "session:"
+	UserSessionsPrefix = "user_sessions:"
 )
 
 type Session struct {
@@ -22,35 +23,48 @@ type Session struct {
 	Expiry time.Time `json:"expiry"`
 }
 
+// SessionStore keeps refresh-token sessions in Redis.
+type SessionStore struct {
+	rdb    *redis.Client
+	clock  Clock
+	random io.Reader
+}
+
+// NewSessionStore returns a store that stamps sessions with clock and draws
+// tokens from random, normally crypto/rand.Reader.
+func NewSessionStore(rdb *redis.Client, clock Clock, random io.Reader) *SessionStore {
+	return &SessionStore{rdb: rdb, clock: clock, random: random}
+}
+
 // hashToken returns the sha256 hash of the token as a hex string.
 func hashToken(token string) string {
 	hash := sha256.Sum256([]byte(token))
 	return hex.EncodeToString(hash[:])
 }
 
-// generateRandomToken generates a cryptographically random 32-byte hex string.
-func generateRandomToken() (string, error) {
+// generateToken reads a 32-byte token from the store's random source and
+// returns it as a hex string.
+func (s *SessionStore) generateToken() (string, error) {
 	b := make([]byte, 32)
-	_, err := rand.Read(b)
+	_, err := io.ReadFull(s.random, b)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate random token: %w", err)
 	}
 	return hex.EncodeToString(b), nil
 }
 
-// CreateRefreshToken generates a new refresh token, hashes it, and stores it in Redis.
+// Create generates a new refresh token, hashes it, and stores it in Redis.
 // Returns the unhashed token.
-func CreateRefreshToken(ctx context.Context, rdb *redis.Client, userID string) (string, error) {
-	token, err := generateRandomToken()
+func (s *SessionStore) Create(ctx context.Context, userID string) (string, error) {
+	token, err := s.generateToken()
 	if err != nil {
 		return "", err
 	}
 
 	hash := hashToken(token)
-	expiry := time.Now().Add(RefreshTokenTTL)
 	session := Session{
 		UserID: userID,
-		Expiry: expiry,
+		Expiry: s.clock.Now().Add(RefreshTokenTTL),
 	}
 
 	data, err := json.Marshal(session)
@@ -58,7 +72,15 @@ func CreateRefreshToken(ctx context.Context, rdb *redis.Client, userID string) (
 		return "", fmt.Errorf("failed to marshal session: %w", err)
 	}
 
-	err = rdb.Set(ctx, SessionPrefix+hash, data, RefreshTokenTTL).Err()
+	// Index the session under its user so all of a user's sessions can be
+	// revoked at once.
+	indexKey := UserSessionsPrefix + userID
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, SessionPrefix+hash, data, RefreshTokenTTL)
+		pipe.SAdd(ctx, indexKey, hash)
+		pipe.Expire(ctx, indexKey, RefreshTokenTTL)
+		return nil
+	})
 	if err != nil {
 		return "", fmt.Errorf("failed to store session in redis: %w", err)
 	}
@@ -66,14 +88,15 @@ func CreateRefreshToken(ctx context.Context, rdb *redis.Client, userID string) (
 	return token, nil
 }
 
-// RotateRefreshToken validates the old token, deletes it from Redis, and issues a new one.
-// Returns the new token and the userID it belongs to.
-func RotateRefreshToken(ctx context.Context, rdb *redis.Client, oldToken string) (string, string, error) {
+// Rotate validates the old token, deletes it from Redis, and issues a new one.
+// Returns the new token and the userID it belongs to. A session past its
+// expiry is rejected even if Redis still holds it.
+func (s *SessionStore) Rotate(ctx context.Context, oldToken string) (string, string, error) {
 	oldHash := hashToken(oldToken)
 	key := SessionPrefix + oldHash
 
 	// 1. Get and validate old token
-	val, err := rdb.Get(ctx, key).Result()
+	val, err := s.rdb.Get(ctx, key).Result()
 	if err == redis.Nil {
 		return "", "", fmt.Errorf("invalid or expired refresh token")
 	} else if err != nil {
@@ -86,13 +109,21 @@ func RotateRefreshToken(ctx context.Context, rdb *redis.Client, oldToken string)
 	}
 
 	// 2. Delete old token
-	err = rdb.Del(ctx, key).Err()
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.SRem(ctx, UserSessionsPrefix+session.UserID, oldHash)
+		return nil
+	})
 	if err != nil {
 		return "", "", fmt.Errorf("failed to delete old session: %w", err)
 	}
 
+	if !s.clock.Now().Before(session.Expiry) {
+		return "", "", fmt.Errorf("invalid or expired refresh token")
+	}
+
 	// 3. Create new token
-	newToken, err := CreateRefreshToken(ctx, rdb, session.UserID)
+	newToken, err := s.Create(ctx, session.UserID)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to create new refresh token: %w", err)
 	}
@@ -100,8 +131,60 @@ func RotateRefreshToken(ctx context.Context, rdb *redis.Client, oldToken string)
 	return newToken, session.UserID, nil
 }
 
-// DeleteRefreshToken removes the refresh token from Redis.
-func DeleteRefreshToken(ctx context.Context, rdb *redis.Client, token string) error {
+// Delete removes the refresh token from Redis.
+func (s *SessionStore) Delete(ctx context.Context, token string) error {
 	hash := hashToken(token)
-	return rdb.Del(ctx, SessionPrefix+hash).Err()
+	key := SessionPrefix + hash
+
+	val, err := s.rdb.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get session from redis: %w", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal([]byte(val), &session); err != nil {
+		return s.rdb.Del(ctx, key).Err()
+	}
+
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.SRem(ctx, UserSessionsPrefix+session.UserID, hash)
+		return nil
+	})
+	return err
+}
+
+// RevokeUser deletes every refresh token issued to userID, signing the user
+// out everywhere once their access tokens expire. It returns the number of
+// sessions removed.
+func (s *SessionStore) RevokeUser(ctx context.Context, userID string) (int, error) {
+	indexKey := UserSessionsPrefix + userID
+
+	hashes, err := s.rdb.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	keys := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		keys = append(keys, SessionPrefix+hash)
+	}
+
+	var deleted *redis.IntCmd
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(keys) > 0 {
+			deleted = pipe.Del(ctx, keys...)
+		}
+		pipe.Del(ctx, indexKey)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	if deleted == nil {
+		return 0, nil
+	}
+	return int(deleted.Val()), nil
 }
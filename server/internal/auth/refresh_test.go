@@ -1,42 +1,29 @@
 package auth
 
 import (
+	"bytes"
 	"context"
-	"os"
+	"crypto/rand"
+	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestRefreshToken(t *testing.T) {
-	redisURL := os.Getenv("REDIS_URL")
-	if redisURL == "" {
-		redisURL = "redis://localhost:6379"
-	}
-
-	opt, err := redis.ParseURL(redisURL)
-	if err != nil {
-		// fallback to direct address if it's not a URL
-		opt = &redis.Options{
-			Addr: "localhost:6379",
-		}
-	}
-
-	rdb := redis.NewClient(opt)
+	rdb := newTestRedis(t)
+	sessions := NewSessionStore(rdb, SystemClock, rand.Reader)
 	ctx := context.Background()
 
-	// Check if redis is available
-	if err := rdb.Ping(ctx).Err(); err != nil {
-		t.Skip("Redis not available, skipping test")
-	}
-	defer rdb.Close()
-
 	userID := "test-user-id"
 
-	t.Run("CreateRefreshToken", func(t *testing.T) {
-		token, err := CreateRefreshToken(ctx, rdb, userID)
+	t.Run("Create", func(t *testing.T) {
+		token, err := sessions.Create(ctx, userID)
 		require.NoError(t, err)
 		assert.NotEmpty(t, token)
 
@@ -47,11 +34,11 @@ func TestRefreshToken(t *testing.T) {
 		assert.Equal(t, int64(1), exists)
 	})
 
-	t.Run("RotateRefreshToken", func(t *testing.T) {
-		token, err := CreateRefreshToken(ctx, rdb, userID)
+	t.Run("Rotate", func(t *testing.T) {
+		token, err := sessions.Create(ctx, userID)
 		require.NoError(t, err)
 
-		newToken, returnedUserID, err := RotateRefreshToken(ctx, rdb, token)
+		newToken, returnedUserID, err := sessions.Rotate(ctx, token)
 		require.NoError(t, err)
 		assert.NotEmpty(t, newToken)
 		assert.NotEqual(t, token, newToken)
@@ -71,16 +58,16 @@ func TestRefreshToken(t *testing.T) {
 	})
 
 	t.Run("RotateInvalidToken", func(t *testing.T) {
-		_, _, err := RotateRefreshToken(ctx, rdb, "invalid-token")
+		_, _, err := sessions.Rotate(ctx, "invalid-token")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid or expired refresh token")
 	})
 
-	t.Run("DeleteRefreshToken", func(t *testing.T) {
-		token, err := CreateRefreshToken(ctx, rdb, userID)
+	t.Run("Delete", func(t *testing.T) {
+		token, err := sessions.Create(ctx, userID)
 		require.NoError(t, err)
 
-		err = DeleteRefreshToken(ctx, rdb, token)
+		err = sessions.Delete(ctx, token)
 		require.NoError(t, err)
 
 		hash := hashToken(token)
@@ -88,4 +75,77 @@ func TestRefreshToken(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, int64(0), exists)
 	})
+
+	t.Run("RevokeUser", func(t *testing.T) {
+		revokedUser := "revoked-user-id"
+		first, err := sessions.Create(ctx, revokedUser)
+		require.NoError(t, err)
+		second, err := sessions.Create(ctx, revokedUser)
+		require.NoError(t, err)
+		other, err := sessions.Create(ctx, userID)
+		require.NoError(t, err)
+
+		n, err := sessions.RevokeUser(ctx, revokedUser)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		exists, err := rdb.Exists(ctx, SessionPrefix+hashToken(first), SessionPrefix+hashToken(second)).Result()
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), exists)
+
+		exists, err = rdb.Exists(ctx, SessionPrefix+hashToken(other)).Result()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), exists)
+	})
+}
+
+func TestSessionStore(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Tokens come from the random source", func(t *testing.T) {
+		random := bytes.NewReader(bytes.Repeat([]byte{0xab}, 32))
+		store := NewSessionStore(newTestRedis(t), &fakeClock{now: start}, random)
+
+		token, err := store.Create(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, strings.Repeat("ab", 32), token)
+
+		_, err = store.Create(ctx, "user-1")
+		assert.ErrorContains(t, err, "failed to generate random token")
+	})
+
+	t.Run("Sessions expire by the clock", func(t *testing.T) {
+		rdb := newTestRedis(t)
+		clock := &fakeClock{now: start}
+		store := NewSessionStore(rdb, clock, rand.Reader)
+
+		token, err := store.Create(ctx, "user-1")
+		require.NoError(t, err)
+
+		var session Session
+		require.NoError(t, json.Unmarshal([]byte(rdb.Get(ctx, SessionPrefix+hashToken(token)).Val()), &session))
+		assert.True(t, session.Expiry.Equal(start.Add(RefreshTokenTTL)))
+
+		clock.Advance(RefreshTokenTTL - time.Minute)
+		token, _, err = store.Rotate(ctx, token)
+		require.NoError(t, err, "rotation inside the TTL succeeds")
+
+		// The new session runs from the rotation, so a full TTL later it
+		// has expired even though Redis still holds it.
+		clock.Advance(RefreshTokenTTL)
+		_, _, err = store.Rotate(ctx, token)
+		assert.ErrorContains(t, err, "expired")
+		assert.Zero(t, rdb.Exists(ctx, SessionPrefix+hashToken(token)).Val(), "expired session is removed")
+	})
+}
+
+// newTestRedis returns a client for an in-memory Redis that lives as long as
+// the test.
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
 }
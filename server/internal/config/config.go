@@ -1,73 +1,205 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"log/slog"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
 )
 
+// DefaultCSPPolicy locks pages served by the API down to same-origin assets
+// and nonce-tagged inline scripts and styles.
+const DefaultCSPPolicy = "default-src 'self'; script-src 'self' {nonce}; style-src 'self' {nonce}; " +
+	"img-src 'self' data:; object-src 'none'; base-uri 'none'; frame-ancestors 'none'"
+
 type Config struct {
-	DBURL               string
-	RedisURL            string
-	MinioEndpoint       string
-	MinioAccessKey      string
-	MinioSecretKey      string
-	JWTPrivateKey       string
-	JWTPublicKey        string
-	Port                string
-	CORSAllowedOrigins string
+	DBURL              string   `setting:"DB_URL" secret:"url"`
+	RedisURL           string   `setting:"REDIS_URL" secret:"url"`
+	MinioEndpoint      string   `setting:"MINIO_ENDPOINT"`
+	MinioAccessKey     string   `setting:"MINIO_ACCESS_KEY"`
+	MinioSecretKey     string   `setting:"MINIO_SECRET_KEY" secret:"true"`
+	JWTPrivateKey      string   `setting:"JWT_PRIVATE_KEY" secret:"true"`
+	JWTPublicKey       string   `setting:"JWT_PUBLIC_KEY"`
+	Port               string   `setting:"PORT"`
+	CORSAllowedOrigins []string `setting:"CORS_ALLOWED_ORIGINS"`
+	CORSPublicOrigins  []string `setting:"CORS_PUBLIC_ORIGINS"`
+
+	// MediaBaseURL is where clients fetch objects from the media bucket,
+	// e.g. a CDN in front of MinIO. Uploaded files' URLs start with it.
+	MediaBaseURL string `setting:"MEDIA_BASE_URL"`
+
+	// HTTP server timeouts. ReadHeaderTimeout is the main defence against
+	// slowloris-style clients that trickle request headers.
+	ReadHeaderTimeout time.Duration `setting:"READ_HEADER_TIMEOUT"`
+	ReadTimeout       time.Duration `setting:"READ_TIMEOUT"`
+	WriteTimeout      time.Duration `setting:"WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `setting:"IDLE_TIMEOUT"`
+
+	// Per-route-group deadlines and request body limits.
+	APITimeout         time.Duration `setting:"API_TIMEOUT"`
+	UploadTimeout      time.Duration `setting:"UPLOAD_TIMEOUT"`
+	MaxJSONBodyBytes   int64         `setting:"MAX_JSON_BODY_BYTES"`
+	MaxUploadBodyBytes int64         `setting:"MAX_UPLOAD_BODY_BYTES"`
+
+	// Security headers. CSPPolicy may contain "{nonce}", which is replaced
+	// with a fresh nonce source on every request.
+	HSTSMaxAge    time.Duration `setting:"HSTS_MAX_AGE"`
+	CSPPolicy     string        `setting:"CSP_POLICY"`
+	CSPReportOnly bool          `setting:"CSP_REPORT_ONLY"`
+
+	// TrustedProxies lists the peers whose Forwarded/X-Forwarded-For headers
+	// are believed when resolving the client IP.
+	TrustedProxies []netip.Prefix `setting:"TRUSTED_PROXIES"`
+
+	// Logging. LogModuleLevels overrides LogLevel per module, parsed from
+	// LOG_MODULE_LEVELS such as "auth=debug,http=warn".
+	LogFormat            string                `setting:"LOG_FORMAT"`
+	LogLevel             slog.Level            `setting:"LOG_LEVEL"`
+	LogModuleLevels      map[string]slog.Level `setting:"LOG_MODULE_LEVELS"`
+	LogSuccessSampleRate float64               `setting:"LOG_SUCCESS_SAMPLE_RATE"`
+
+	// Probes and shutdown. During ShutdownDrainDelay /readyz already fails
+	// but the server still accepts requests.
+	HealthCheckTimeout time.Duration `setting:"HEALTH_CHECK_TIMEOUT"`
+	HealthCacheTTL     time.Duration `setting:"HEALTH_CACHE_TTL"`
+	ShutdownDrainDelay time.Duration `setting:"SHUTDOWN_DRAIN_DELAY"`
+
+	// Startup. Postgres and Redis are retried with backoff for up to
+	// StartupRetryTimeout before the process gives up; MinIO gets
+	// OptionalStartupTimeout and the server starts degraded without it.
+	StartupRetryTimeout    time.Duration `setting:"STARTUP_RETRY_TIMEOUT"`
+	OptionalStartupTimeout time.Duration `setting:"OPTIONAL_STARTUP_TIMEOUT"`
+
+	// MigrateOnStart applies pending migrations at boot. Either way the
+	// server refuses to start against a schema newer than it knows.
+	MigrateOnStart bool `setting:"MIGRATE_ON_START"`
+
+	// SuggestionsRefreshInterval is how often the follow suggestions of
+	// recently active users are recomputed. Zero turns the refresh off;
+	// suggestions are then only computed when a user has none cached.
+	SuggestionsRefreshInterval time.Duration `setting:"SUGGESTIONS_REFRESH_INTERVAL"`
+
+	// AccountPurgeInterval is how often accounts past their deletion grace
+	// period are purged. Zero turns the purge off.
+	AccountPurgeInterval time.Duration `setting:"ACCOUNT_PURGE_INTERVAL"`
 }
 
+// Load reads the configuration. Environment variables take precedence over
+// the YAML or TOML files listed in CONFIG_FILE (comma-separated, later files
+// overriding earlier ones), which take precedence over the defaults. Any
+// setting can instead be read from a file by setting KEY_FILE, e.g.
+// JWT_PRIVATE_KEY_FILE=/run/secrets/jwt.pem.
+//
+// Every problem found is reported, joined into a single error.
 func Load() (*Config, error) {
+	env := envLayer()
+	layers := []layer{env}
+
+	var fileErrs []error
+	paths := strings.Split(env.values["CONFIG_FILE"], ",")
+	for i := len(paths) - 1; i >= 0; i-- {
+		path := strings.TrimSpace(paths[i])
+		if path == "" {
+			continue
+		}
+		ly, err := fileLayer(path)
+		if err != nil {
+			fileErrs = append(fileErrs, err)
+			continue
+		}
+		layers = append(layers, ly)
+	}
+
+	l := newLoader(layers...)
+	l.errs = fileErrs
+
 	config := &Config{
-		DBURL:               getEnv("DB_URL", ""),
-		RedisURL:            getEnv("REDIS_URL", ""),
-		MinioEndpoint:       getEnv("MINIO_ENDPOINT", ""),
-		MinioAccessKey:      getEnv("MINIO_ACCESS_KEY", ""),
-		MinioSecretKey:      getEnv("MINIO_SECRET_KEY", ""),
-		JWTPrivateKey:       getEnv("JWT_PRIVATE_KEY", ""),
-		JWTPublicKey:        getEnv("JWT_PUBLIC_KEY", ""),
-		Port:                getEnv("PORT", "8080"),
-		CORSAllowedOrigins: getEnv("CORS_ALLOWED_ORIGINS", ""),
+		DBURL:              l.string("DB_URL", ""),
+		RedisURL:           l.string("REDIS_URL", ""),
+		MinioEndpoint:      l.string("MINIO_ENDPOINT", ""),
+		MinioAccessKey:     l.string("MINIO_ACCESS_KEY", ""),
+		MinioSecretKey:     l.string("MINIO_SECRET_KEY", ""),
+		JWTPrivateKey:      l.string("JWT_PRIVATE_KEY", ""),
+		JWTPublicKey:       l.string("JWT_PUBLIC_KEY", ""),
+		Port:               l.string("PORT", "8080"),
+		CORSAllowedOrigins: l.list("CORS_ALLOWED_ORIGINS", nil),
+		CORSPublicOrigins:  l.list("CORS_PUBLIC_ORIGINS", []string{"*"}),
+		MediaBaseURL:       l.string("MEDIA_BASE_URL", "http://localhost:9000/media"),
+
+		ReadHeaderTimeout:  l.duration("READ_HEADER_TIMEOUT", 5*time.Second),
+		ReadTimeout:        l.duration("READ_TIMEOUT", 60*time.Second),
+		WriteTimeout:       l.duration("WRITE_TIMEOUT", 60*time.Second),
+		IdleTimeout:        l.duration("IDLE_TIMEOUT", 120*time.Second),
+		APITimeout:         l.duration("API_TIMEOUT", 10*time.Second),
+		UploadTimeout:      l.duration("UPLOAD_TIMEOUT", 55*time.Second),
+		MaxJSONBodyBytes:   l.int64("MAX_JSON_BODY_BYTES", 1<<20),
+		MaxUploadBodyBytes: l.int64("MAX_UPLOAD_BODY_BYTES", 50<<20),
+
+		HSTSMaxAge:    l.duration("HSTS_MAX_AGE", 180*24*time.Hour),
+		CSPPolicy:     l.string("CSP_POLICY", DefaultCSPPolicy),
+		CSPReportOnly: l.bool("CSP_REPORT_ONLY", false),
+
+		TrustedProxies: l.prefixes("TRUSTED_PROXIES"),
+
+		LogFormat:            l.string("LOG_FORMAT", "json"),
+		LogLevel:             l.level("LOG_LEVEL", slog.LevelInfo),
+		LogModuleLevels:      l.moduleLevels("LOG_MODULE_LEVELS"),
+		LogSuccessSampleRate: l.float("LOG_SUCCESS_SAMPLE_RATE", 1),
+
+		HealthCheckTimeout: l.duration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		HealthCacheTTL:     l.duration("HEALTH_CACHE_TTL", time.Second),
+		ShutdownDrainDelay: l.duration("SHUTDOWN_DRAIN_DELAY", 0),
+
+		StartupRetryTimeout:    l.duration("STARTUP_RETRY_TIMEOUT", time.Minute),
+		OptionalStartupTimeout: l.duration("OPTIONAL_STARTUP_TIMEOUT", 10*time.Second),
+		MigrateOnStart:         l.bool("MIGRATE_ON_START", false),
+
+		SuggestionsRefreshInterval: l.duration("SUGGESTIONS_REFRESH_INTERVAL", time.Hour),
+		AccountPurgeInterval:       l.duration("ACCOUNT_PURGE_INTERVAL", time.Hour),
 	}
+	l.unknownKeys()
 
-	if err := config.Validate(); err != nil {
+	if err := errors.Join(l.err(), config.Validate()); err != nil {
 		return nil, err
 	}
 
 	return config, nil
 }
 
+// Validate checks the configuration and returns every problem it finds,
+// joined into a single error.
 func (c *Config) Validate() error {
-	if c.DBURL == "" {
-		return fmt.Errorf("DB_URL is required")
-	}
-	if c.RedisURL == "" {
-		return fmt.Errorf("REDIS_URL is required")
-	}
-	if c.MinioEndpoint == "" {
-		return fmt.Errorf("MINIO_ENDPOINT is required")
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
 	}
-	if c.MinioAccessKey == "" {
-		return fmt.Errorf("MINIO_ACCESS_KEY is required")
-	}
-	if c.MinioSecretKey == "" {
-		return fmt.Errorf("MINIO_SECRET_KEY is required")
-	}
-	if c.JWTPrivateKey == "" {
-		return fmt.Errorf("JWT_PRIVATE_KEY is required")
-	}
-	if c.JWTPublicKey == "" {
-		return fmt.Errorf("JWT_PUBLIC_KEY is required")
-	}
-	if c.Port == "" {
-		return fmt.Errorf("PORT is required")
-	}
-	return nil
-}
 
-func getEnv(key, fallback string) string {
-	if value, ok := os.LookupEnv(key); ok {
-		return value
-	}
-	return fallback
+	check(c.DBURL != "", "DB_URL is required")
+	check(c.RedisURL != "", "REDIS_URL is required")
+	check(c.MinioEndpoint != "", "MINIO_ENDPOINT is required")
+	check(c.MinioAccessKey != "", "MINIO_ACCESS_KEY is required")
+	check(c.MinioSecretKey != "", "MINIO_SECRET_KEY is required")
+	mediaURL, err := url.Parse(c.MediaBaseURL)
+	check(err == nil && mediaURL.IsAbs() && mediaURL.Host != "", "MEDIA_BASE_URL must be an absolute URL")
+	check(c.JWTPrivateKey != "", "JWT_PRIVATE_KEY is required")
+	check(c.JWTPublicKey != "", "JWT_PUBLIC_KEY is required")
+	check(c.Port != "", "PORT is required")
+	check(c.ReadHeaderTimeout > 0, "READ_HEADER_TIMEOUT must be positive")
+	check(c.APITimeout <= c.WriteTimeout && c.UploadTimeout <= c.WriteTimeout,
+		"API_TIMEOUT and UPLOAD_TIMEOUT must not exceed WRITE_TIMEOUT")
+	check(c.MaxJSONBodyBytes > 0 && c.MaxUploadBodyBytes > 0,
+		"MAX_JSON_BODY_BYTES and MAX_UPLOAD_BODY_BYTES must be positive")
+	check(c.LogFormat == "json" || c.LogFormat == "text", "LOG_FORMAT must be json or text")
+	check(c.LogSuccessSampleRate >= 0 && c.LogSuccessSampleRate <= 1,
+		"LOG_SUCCESS_SAMPLE_RATE must be between 0 and 1")
+	check(c.StartupRetryTimeout > 0, "STARTUP_RETRY_TIMEOUT must be positive")
+	check(c.SuggestionsRefreshInterval >= 0, "SUGGESTIONS_REFRESH_INTERVAL must not be negative")
+	check(c.AccountPurgeInterval >= 0, "ACCOUNT_PURGE_INTERVAL must not be negative")
+
+	return errors.Join(errs...)
 }
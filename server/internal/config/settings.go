@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Masked replaces secret values in Settings.
+const Masked = "********"
+
+// dsnPassword matches the password in a key=value connection string, which
+// may be single-quoted to contain spaces.
+var dsnPassword = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|\S*)`)
+
+// Setting is one configuration value as it would be written in the
+// environment.
+type Setting struct {
+	Key   string
+	Value string
+}
+
+// Settings returns the effective configuration in declaration order, with
+// secrets masked. Passwords embedded in URL settings are masked but the rest
+// of the URL is kept, since the host is usually what one wants to check.
+func (c *Config) Settings() []Setting {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	settings := make([]Setting, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("setting")
+		if key == "" {
+			continue
+		}
+
+		value := formatSetting(v.Field(i).Interface())
+		switch field.Tag.Get("secret") {
+		case "true":
+			if value != "" {
+				value = Masked
+			}
+		case "url":
+			value = maskURL(value)
+		}
+		settings = append(settings, Setting{Key: key, Value: value})
+	}
+	return settings
+}
+
+func formatSetting(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ",")
+	case time.Duration:
+		return v.String()
+	case slog.Level:
+		return strings.ToLower(v.String())
+	case []netip.Prefix:
+		items := make([]string, len(v))
+		for i, p := range v {
+			items[i] = p.String()
+		}
+		return strings.Join(items, ",")
+	case map[string]slog.Level:
+		items := make([]string, 0, len(v))
+		for module, level := range v {
+			items = append(items, module+"="+strings.ToLower(level.String()))
+		}
+		sort.Strings(items)
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func maskURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	if strings.Contains(raw, "://") {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return Masked
+		}
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), Masked)
+		}
+		if q := u.Query(); q.Has("password") {
+			q.Set("password", Masked)
+			u.RawQuery = q.Encode()
+		}
+		return u.String()
+	}
+	// A key=value connection string, as Postgres also accepts.
+	if strings.Contains(raw, "=") {
+		return dsnPassword.ReplaceAllString(raw, "${1}"+Masked)
+	}
+	// Anything else might be a secret in a format we don't know.
+	return Masked
+}
@@ -0,0 +1,297 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// layer is one source of settings, keyed by environment variable name.
+type layer struct {
+	name   string
+	values map[string]string
+	file   bool
+}
+
+func envLayer() layer {
+	values := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			values[key] = value
+		}
+	}
+	return layer{name: "environment", values: values}
+}
+
+// fileLayer reads a YAML (.yaml, .yml) or TOML (.toml) config file. Nested
+// tables are flattened into environment-style keys, so
+//
+//	log:
+//	  level: debug
+//
+// sets LOG_LEVEL. Lists become comma-separated values.
+func fileLayer(path string) (layer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return layer{}, err
+	}
+
+	raw := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return layer{}, fmt.Errorf("%s: unsupported config file type, want .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return layer{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten("", raw, values)
+	return layer{name: path, values: values, file: true}, nil
+}
+
+func flatten(prefix string, v any, out map[string]string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			key := strings.ToUpper(k)
+			if prefix != "" {
+				key = prefix + "_" + key
+			}
+			flatten(key, child, out)
+		}
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		out[prefix] = strings.Join(items, ",")
+	case nil:
+		out[prefix] = ""
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+}
+
+// loader resolves settings across layers and collects every problem it
+// finds, so a bad config is reported in one go rather than one error per
+// restart.
+type loader struct {
+	layers []layer // highest precedence first
+	used   map[string]bool
+	errs   []error
+}
+
+func newLoader(layers ...layer) *loader {
+	return &loader{layers: layers, used: make(map[string]bool)}
+}
+
+func (l *loader) errorf(format string, args ...any) {
+	l.errs = append(l.errs, fmt.Errorf(format, args...))
+}
+
+// lookup returns the value of key from the highest-precedence layer that sets
+// it, either directly or as KEY_FILE naming a file that holds the value. An
+// empty value counts as unset, so a blank line in .env doesn't mask a
+// setting from a config file.
+func (l *loader) lookup(key string) (string, bool) {
+	return l.find(key, false)
+}
+
+// find is lookup, except that with keepEmpty a key a config file sets to
+// nothing, such as an empty list, counts as set.
+func (l *loader) find(key string, keepEmpty bool) (string, bool) {
+	l.used[key] = true
+	l.used[key+"_FILE"] = true
+
+	for _, ly := range l.layers {
+		value, present := ly.values[key]
+		path := ly.values[key+"_FILE"]
+		switch {
+		case value != "" && path != "":
+			l.errorf("%s and %s_FILE are both set in %s", key, key, ly.name)
+			return "", false
+		case path != "":
+			data, err := os.ReadFile(path)
+			if err != nil {
+				l.errorf("%s_FILE: %w", key, err)
+				return "", false
+			}
+			return strings.TrimRight(string(data), "\r\n"), true
+		case value != "" || keepEmpty && present && ly.file:
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// unknownKeys reports keys in config files that no setting reads, which are
+// almost always typos.
+func (l *loader) unknownKeys() {
+	for _, ly := range l.layers {
+		if !ly.file {
+			continue
+		}
+		var unknown []string
+		for key := range ly.values {
+			if !l.used[key] {
+				unknown = append(unknown, key)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			l.errorf("%s: unknown settings %s", ly.name, strings.Join(unknown, ", "))
+		}
+	}
+}
+
+func (l *loader) err() error {
+	return errors.Join(l.errs...)
+}
+
+func (l *loader) string(key, fallback string) string {
+	if value, ok := l.lookup(key); ok {
+		return value
+	}
+	return fallback
+}
+
+func (l *loader) duration(key string, fallback time.Duration) time.Duration {
+	value, ok := l.lookup(key)
+	if !ok {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		l.errorf("%s must be a duration such as 30s or 5m: %w", key, err)
+		return fallback
+	}
+	return d
+}
+
+func (l *loader) int64(key string, fallback int64) int64 {
+	value, ok := l.lookup(key)
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		l.errorf("%s must be an integer: %w", key, err)
+		return fallback
+	}
+	return n
+}
+
+func (l *loader) bool(key string, fallback bool) bool {
+	value, ok := l.lookup(key)
+	if !ok {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		l.errorf("%s must be true or false: %w", key, err)
+		return fallback
+	}
+	return b
+}
+
+func (l *loader) float(key string, fallback float64) float64 {
+	value, ok := l.lookup(key)
+	if !ok {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		l.errorf("%s must be a number: %w", key, err)
+		return fallback
+	}
+	return f
+}
+
+// list reads a comma-separated list, dropping blank entries. A config file
+// can set an empty list to turn off a default.
+func (l *loader) list(key string, fallback []string) []string {
+	value, ok := l.find(key, true)
+	if !ok {
+		return fallback
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (l *loader) level(key string, fallback slog.Level) slog.Level {
+	value, ok := l.lookup(key)
+	if !ok {
+		return fallback
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		l.errorf("%s must be debug, info, warn or error: %w", key, err)
+		return fallback
+	}
+	return level
+}
+
+// prefixes reads a list of CIDRs or bare IPs, such as
+// "10.0.0.0/8, 172.16.0.0/12, ::1".
+func (l *loader) prefixes(key string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, entry := range l.list(key, nil) {
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				l.errorf("%s contains an invalid CIDR %q: %w", key, entry, err)
+				continue
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			l.errorf("%s contains an invalid IP %q: %w", key, entry, err)
+			continue
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes
+}
+
+// moduleLevels reads "module=level" pairs such as "auth=debug,http=warn".
+func (l *loader) moduleLevels(key string) map[string]slog.Level {
+	levels := make(map[string]slog.Level)
+	for _, entry := range l.list(key, nil) {
+		module, level, ok := strings.Cut(entry, "=")
+		if !ok {
+			l.errorf("%s entry %q must look like module=level", key, entry)
+			continue
+		}
+		var lvl slog.Level
+		if err := lvl.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+			l.errorf("%s entry %q has an invalid level: %w", key, entry, err)
+			continue
+		}
+		levels[strings.TrimSpace(module)] = lvl
+	}
+	return levels
+}
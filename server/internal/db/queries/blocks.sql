@@ -0,0 +1,21 @@
+-- name: CreateBlock :execrows
+-- CreateBlock is idempotent: blocking someone twice affects no rows.
+INSERT INTO blocks (blocker_id, blocked_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBlock :execrows
+DELETE FROM blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: UpsertMute :exec
+-- UpsertMute mutes muted_id for muter_id until expires_at, or for good if it
+-- is null, replacing any earlier mute.
+INSERT INTO mutes (muter_id, muted_id, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (muter_id, muted_id) DO UPDATE
+SET expires_at = EXCLUDED.expires_at, created_at = NOW();
+
+-- name: DeleteMute :execrows
+DELETE FROM mutes
+WHERE muter_id = $1 AND muted_id = $2;
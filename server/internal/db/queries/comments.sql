@@ -0,0 +1,75 @@
+-- name: CreateComment :one
+INSERT INTO comments (post_id, user_id, parent_id, content)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetComment :one
+-- GetComment returns a live comment if viewer_id may read it and its post.
+SELECT c.* FROM comments c
+JOIN posts p ON p.id = c.post_id
+WHERE c.id = @id
+  AND c.deleted_at IS NULL
+  AND can_view_posts(@viewer_id, p.user_id)
+  AND visible_to(@viewer_id, c.user_id, NULL);
+
+-- name: ListPostComments :many
+-- ListPostComments pages through a post's comments and replies, oldest
+-- first, so parents always come before their replies. It is empty if
+-- viewer_id may not read the post, and skips comments by users on the other
+-- side of a block. A comment that was deleted, or whose author is
+-- deactivated or gone, is a tombstone: it is listed without author or
+-- content while it has replies, so the thread below it still renders, and
+-- skipped otherwise.
+SELECT c.id, c.post_id,
+       CASE WHEN t.tombstone THEN NULL ELSE c.user_id END AS user_id,
+       c.parent_id,
+       CASE WHEN t.tombstone THEN '' ELSE c.content END AS content,
+       c.created_at, c.deleted_at
+FROM comments c
+CROSS JOIN LATERAL (
+    SELECT c.deleted_at IS NOT NULL
+        OR (c.user_id IS DISTINCT FROM @viewer_id AND NOT account_active(c.user_id)) AS tombstone
+) t
+WHERE c.post_id = @post_id
+  AND can_view_posts(@viewer_id, (SELECT user_id FROM posts WHERE id = @post_id))
+  AND (c.user_id IS NULL OR NOT blocked_between(@viewer_id, c.user_id))
+  AND (NOT t.tombstone OR EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = c.id))
+  AND (sqlc.narg('after_created_at')::timestamptz IS NULL
+       OR (c.created_at, c.id) > (sqlc.narg('after_created_at')::timestamptz, sqlc.narg('after_id')::uuid))
+ORDER BY c.created_at, c.id
+LIMIT @page_size;
+
+-- name: CountPostComments :one
+SELECT count(*) FROM comments
+WHERE post_id = $1 AND deleted_at IS NULL;
+
+-- name: SoftDeleteComment :execrows
+UPDATE comments
+SET deleted_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;
+
+-- name: PurgeUserComments :exec
+-- PurgeUserComments deletes user_id's comments ahead of the account
+-- itself, except those with a reply by someone else somewhere below them:
+-- those are emptied into tombstones, and lose their author when the
+-- account goes, so the replies keep their place in the thread.
+WITH RECURSIVE thread AS (
+    SELECT id AS root, id, user_id FROM comments WHERE user_id = @user_id
+    UNION ALL
+    SELECT t.root, c.id, c.user_id
+    FROM comments c
+    JOIN thread t ON c.parent_id = t.id
+),
+kept AS (
+    SELECT DISTINCT root AS id FROM thread
+    WHERE user_id IS DISTINCT FROM @user_id
+),
+emptied AS (
+    UPDATE comments c
+    SET content = '', deleted_at = COALESCE(c.deleted_at, @now)
+    FROM kept
+    WHERE c.id = kept.id
+)
+DELETE FROM comments
+WHERE user_id = @user_id
+  AND id NOT IN (SELECT id FROM kept);
@@ -0,0 +1,34 @@
+-- name: CreateFollowRequest :execrows
+-- CreateFollowRequest is idempotent: asking twice affects no rows.
+INSERT INTO follow_requests (requester_id, target_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteFollowRequest :execrows
+DELETE FROM follow_requests
+WHERE requester_id = $1 AND target_id = $2;
+
+-- name: ListFollowRequests :many
+-- ListFollowRequests pages through the requests awaiting target_id's
+-- answer, most recent first.
+SELECT u.id, u.username, u.display_name, u.avatar_url, r.created_at AS requested_at
+FROM follow_requests r
+JOIN users u ON u.id = r.requester_id
+WHERE r.target_id = @target_id
+  AND u.deactivated_at IS NULL
+  AND (sqlc.narg('before_requested_at')::timestamptz IS NULL
+       OR (r.created_at, r.requester_id) < (sqlc.narg('before_requested_at')::timestamptz, sqlc.narg('before_id')::uuid))
+ORDER BY r.created_at DESC, r.requester_id DESC
+LIMIT @page_size;
+
+-- name: AcceptAllFollowRequests :execrows
+-- AcceptAllFollowRequests turns every request awaiting target_id into a
+-- follow, for when the account goes public.
+WITH accepted AS (
+    DELETE FROM follow_requests
+    WHERE target_id = $1
+    RETURNING requester_id, target_id
+)
+INSERT INTO follows (follower_id, following_id)
+SELECT requester_id, target_id FROM accepted
+ON CONFLICT DO NOTHING;
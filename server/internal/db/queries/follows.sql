@@ -0,0 +1,86 @@
+-- name: CreateFollow :execrows
+-- CreateFollow is idempotent: following someone twice affects no rows.
+INSERT INTO follows (follower_id, following_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteFollow :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND following_id = $2;
+
+-- name: IsFollowing :one
+SELECT EXISTS (
+    SELECT 1 FROM follows
+    WHERE follower_id = $1 AND following_id = $2
+);
+
+-- name: ListFollowers :many
+-- ListFollowers pages through the accounts following a user, most recent
+-- first, flagging the ones viewer_id follows. Deactivated accounts and
+-- ones with a block between them and viewer_id are left out.
+SELECT u.id, u.username, u.display_name, u.avatar_url, f.created_at AS followed_at,
+       EXISTS (
+           SELECT 1 FROM follows v
+           WHERE v.follower_id = @viewer_id AND v.following_id = u.id
+       ) AS is_following
+FROM follows f
+JOIN users u ON u.id = f.follower_id
+WHERE f.following_id = @user_id
+  AND u.deactivated_at IS NULL
+  AND visible_to(@viewer_id, u.id, NULL)
+  AND (sqlc.narg('before_followed_at')::timestamptz IS NULL
+       OR (f.created_at, f.follower_id) < (sqlc.narg('before_followed_at')::timestamptz, sqlc.narg('before_id')::uuid))
+ORDER BY f.created_at DESC, f.follower_id DESC
+LIMIT @page_size;
+
+-- name: ListFollowing :many
+-- ListFollowing pages through the accounts a user follows, most recent
+-- first, flagging the ones viewer_id follows. Deactivated accounts and
+-- ones with a block between them and viewer_id are left out.
+SELECT u.id, u.username, u.display_name, u.avatar_url, f.created_at AS followed_at,
+       EXISTS (
+           SELECT 1 FROM follows v
+           WHERE v.follower_id = @viewer_id AND v.following_id = u.id
+       ) AS is_following
+FROM follows f
+JOIN users u ON u.id = f.following_id
+WHERE f.follower_id = @user_id
+  AND u.deactivated_at IS NULL
+  AND visible_to(@viewer_id, u.id, NULL)
+  AND (sqlc.narg('before_followed_at')::timestamptz IS NULL
+       OR (f.created_at, f.following_id) < (sqlc.narg('before_followed_at')::timestamptz, sqlc.narg('before_id')::uuid))
+ORDER BY f.created_at DESC, f.following_id DESC
+LIMIT @page_size;
+
+-- name: CountFollowers :one
+SELECT count(*) FROM follows
+WHERE following_id = $1;
+
+-- name: CountFollowing :one
+SELECT count(*) FROM follows
+WHERE follower_id = $1;
+
+-- name: ListMutualFollowers :many
+-- ListMutualFollowers pages through the followers of user_id that viewer_id
+-- also follows: "followed by people you follow". Like the other lists it
+-- leaves out accounts with a block between them and viewer_id.
+SELECT u.id, u.username, u.display_name, u.avatar_url, f.created_at AS followed_at
+FROM follows f
+JOIN follows v ON v.following_id = f.follower_id AND v.follower_id = @viewer_id
+JOIN users u ON u.id = f.follower_id
+WHERE f.following_id = @user_id
+  AND u.deactivated_at IS NULL
+  AND visible_to(@viewer_id, u.id, NULL)
+  AND (sqlc.narg('before_followed_at')::timestamptz IS NULL
+       OR (f.created_at, f.follower_id) < (sqlc.narg('before_followed_at')::timestamptz, sqlc.narg('before_id')::uuid))
+ORDER BY f.created_at DESC, f.follower_id DESC
+LIMIT @page_size;
+
+-- name: CountMutualFollowers :one
+SELECT count(*)
+FROM follows f
+JOIN follows v ON v.following_id = f.follower_id AND v.follower_id = @viewer_id
+JOIN users u ON u.id = f.follower_id
+WHERE f.following_id = @user_id
+  AND u.deactivated_at IS NULL
+  AND visible_to(@viewer_id, u.id, NULL);
@@ -0,0 +1,19 @@
+-- name: CreateLike :execrows
+-- CreateLike is idempotent: liking a post twice affects no rows.
+INSERT INTO likes (user_id, post_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteLike :execrows
+DELETE FROM likes
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: HasLiked :one
+SELECT EXISTS (
+    SELECT 1 FROM likes
+    WHERE user_id = $1 AND post_id = $2
+);
+
+-- name: CountPostLikes :one
+SELECT count(*) FROM likes
+WHERE post_id = $1;
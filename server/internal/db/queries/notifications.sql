@@ -0,0 +1,51 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (recipient_id, actor_id, type, entity_id, entity_type)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListNotifications :many
+-- ListNotifications pages through a user's notifications, newest first,
+-- hiding those from blocked accounts and accounts muted as of now.
+SELECT * FROM notifications
+WHERE recipient_id = @recipient_id
+  AND visible_to(recipient_id, actor_id, @now)
+  AND (sqlc.narg('before_created_at')::timestamptz IS NULL
+       OR (created_at, id) < (sqlc.narg('before_created_at')::timestamptz, sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT @page_size;
+
+-- name: CountUnreadNotifications :one
+-- CountUnreadNotifications counts what ListNotifications would show unread.
+SELECT count(*) FROM notifications
+WHERE recipient_id = @recipient_id
+  AND NOT is_read
+  AND visible_to(recipient_id, actor_id, @now);
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications
+SET is_read = TRUE
+WHERE id = $1 AND recipient_id = $2;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET is_read = TRUE
+WHERE recipient_id = $1 AND NOT is_read;
+
+-- name: DeleteFollowNotification :execrows
+-- DeleteFollowNotification withdraws the notice of a follow that was undone.
+DELETE FROM notifications
+WHERE recipient_id = $1 AND actor_id = $2 AND type = 'follow';
+
+-- name: DeleteFollowRequestNotification :execrows
+DELETE FROM notifications
+WHERE recipient_id = $1 AND actor_id = $2 AND type = 'follow_request';
+
+-- name: PromoteFollowRequestNotifications :execrows
+-- PromoteFollowRequestNotifications turns the notices of accepted follow
+-- requests into follow notices, from actor_id only or, if it is null, from
+-- everyone.
+UPDATE notifications
+SET type = 'follow'
+WHERE recipient_id = @recipient_id
+  AND type = 'follow_request'
+  AND (sqlc.narg('actor_id')::uuid IS NULL OR actor_id = sqlc.narg('actor_id')::uuid);
@@ -0,0 +1,46 @@
+-- name: CreatePost :one
+INSERT INTO posts (user_id, content, media_urls)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetPost :one
+-- GetPost returns a live post if viewer_id may read it.
+SELECT p.* FROM posts p
+WHERE p.id = @id
+  AND p.deleted_at IS NULL
+  AND can_view_posts(@viewer_id, p.user_id);
+
+-- name: ListUserPosts :many
+-- ListUserPosts pages through a user's posts, newest first, and is empty
+-- if viewer_id may not read them. Pass the last row's created_at and id as
+-- the cursor to fetch the next page.
+SELECT * FROM posts
+WHERE user_id = @user_id
+  AND deleted_at IS NULL
+  AND can_view_posts(@viewer_id, user_id)
+  AND (sqlc.narg('before_created_at')::timestamptz IS NULL
+       OR (created_at, id) < (sqlc.narg('before_created_at')::timestamptz, sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT @page_size;
+
+-- name: ListFeed :many
+-- ListFeed pages through posts by the viewer and the accounts they follow,
+-- newest first, leaving out the accounts muted as of now.
+SELECT p.* FROM posts p
+WHERE p.deleted_at IS NULL
+  AND (p.user_id = @viewer_id
+       OR p.user_id IN (SELECT following_id FROM follows WHERE follower_id = @viewer_id))
+  AND visible_to(@viewer_id, p.user_id, @now)
+  AND (sqlc.narg('before_created_at')::timestamptz IS NULL
+       OR (p.created_at, p.id) < (sqlc.narg('before_created_at')::timestamptz, sqlc.narg('before_id')::uuid))
+ORDER BY p.created_at DESC, p.id DESC
+LIMIT @page_size;
+
+-- name: CountUserPosts :one
+SELECT count(*) FROM posts
+WHERE user_id = $1 AND deleted_at IS NULL;
+
+-- name: SoftDeletePost :execrows
+UPDATE posts
+SET deleted_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;
@@ -0,0 +1,71 @@
+-- name: SearchUsers :many
+-- SearchUsers ranks the accounts matching query for viewer_id, best first.
+-- The text score is the trigram similarity of the username or display
+-- name, boosted when the username is the query or starts with it and when
+-- a word of the display name starts with it; following the account, or
+-- being followed by it, adds to that. prefix is the query with LIKE
+-- wildcards escaped. Accounts blocked either way are left out.
+WITH term AS (
+    SELECT search_fold(@query) AS query, search_fold(@prefix) AS prefix
+),
+matches AS (
+    SELECT u.id, u.username, u.display_name, u.avatar_url,
+           EXISTS (
+               SELECT 1 FROM follows f
+               WHERE f.follower_id = @viewer_id AND f.following_id = u.id
+           ) AS is_following,
+           EXISTS (
+               SELECT 1 FROM follows f
+               WHERE f.follower_id = u.id AND f.following_id = @viewer_id
+           ) AS follows_you,
+           greatest(similarity(search_fold(u.username), term.query),
+                    similarity(search_fold(u.display_name), term.query))::float8
+               + CASE WHEN search_fold(u.username) = term.query THEN 2
+                      WHEN search_fold(u.username) LIKE term.prefix || '%' THEN 1
+                      ELSE 0 END
+               + CASE WHEN ' ' || search_fold(u.display_name) LIKE '% ' || term.prefix || '%' THEN 0.5
+                      ELSE 0 END AS text_score
+    FROM users u, term
+    WHERE (search_fold(u.username) % term.query
+           OR search_fold(u.display_name) % term.query
+           OR search_fold(u.username) LIKE term.prefix || '%'
+           OR search_fold(u.display_name) LIKE '%' || term.prefix || '%')
+      AND visible_to(@viewer_id, u.id, NULL)
+),
+ranked AS (
+    SELECT m.id, m.username, m.display_name, m.avatar_url, m.is_following, m.follows_you,
+           m.text_score
+               + CASE WHEN m.is_following THEN 0.6 ELSE 0 END
+               + CASE WHEN m.follows_you THEN 0.3 ELSE 0 END AS rank
+    FROM matches m
+)
+SELECT id, username, display_name, avatar_url, is_following, follows_you, rank
+FROM ranked
+WHERE sqlc.narg('before_rank')::float8 IS NULL
+   OR (rank, id) < (sqlc.narg('before_rank')::float8, sqlc.narg('before_id')::uuid)
+ORDER BY rank DESC, id DESC
+LIMIT @page_size;
+
+-- name: TypeaheadUsers :many
+-- TypeaheadUsers completes a partial name for mention autocompletion: only
+-- usernames, or display name words, starting with prefix match. People
+-- viewer_id follows come first, then people following them, then username
+-- matches and shorter names. prefix has its LIKE wildcards escaped.
+WITH term AS (
+    SELECT search_fold(@prefix) AS prefix
+)
+SELECT u.id, u.username, u.display_name, u.avatar_url
+FROM users u
+CROSS JOIN term
+LEFT JOIN follows f ON f.follower_id = @viewer_id AND f.following_id = u.id
+LEFT JOIN follows b ON b.follower_id = u.id AND b.following_id = @viewer_id
+WHERE (search_fold(u.username) LIKE term.prefix || '%'
+       OR (search_fold(u.display_name) LIKE '%' || term.prefix || '%'
+           AND ' ' || search_fold(u.display_name) LIKE '% ' || term.prefix || '%'))
+  AND visible_to(@viewer_id, u.id, NULL)
+ORDER BY f.follower_id IS NOT NULL DESC,
+         b.follower_id IS NOT NULL DESC,
+         search_fold(u.username) LIKE term.prefix || '%' DESC,
+         length(u.username),
+         u.username
+LIMIT @page_size;
@@ -0,0 +1,80 @@
+-- name: ListSuggestionCandidates :many
+-- ListSuggestionCandidates ranks accounts for user_id to follow. Candidates
+-- are followed by people user_id follows (mutual_count), followed by people
+-- who follow user_id (shared_follower_count), follow user_id already, or
+-- are among the popular_pool most-followed accounts. Each signal adds to
+-- the score along with posts since active_since and follower count, both
+-- damped so big accounts don't drown out the graph. Accounts user_id
+-- follows, has asked to follow, or can't see as of now are left out.
+WITH following AS (
+    SELECT following_id AS id FROM follows WHERE follower_id = @user_id
+),
+followers AS (
+    SELECT follower_id AS id FROM follows WHERE following_id = @user_id
+),
+second_degree AS (
+    SELECT f.following_id AS id, count(*) AS n
+    FROM follows f
+    JOIN following ON following.id = f.follower_id
+    GROUP BY f.following_id
+),
+shared_followers AS (
+    SELECT f.following_id AS id, count(*) AS n
+    FROM follows f
+    JOIN followers ON followers.id = f.follower_id
+    GROUP BY f.following_id
+),
+popular AS (
+    SELECT user_id AS id FROM user_stats
+    ORDER BY follower_count DESC
+    LIMIT @popular_pool
+),
+candidates AS (
+    SELECT id FROM second_degree
+    UNION SELECT id FROM shared_followers
+    UNION SELECT id FROM followers
+    UNION SELECT id FROM popular
+)
+SELECT c.id,
+       coalesce(sd.n, 0)::bigint AS mutual_count,
+       coalesce(sf.n, 0)::bigint AS shared_follower_count,
+       (3 * coalesce(sd.n, 0)
+        + 2 * coalesce(sf.n, 0)
+        + CASE WHEN c.id IN (SELECT id FROM followers) THEN 4 ELSE 0 END
+        + least(recent.n, 10) / 5.0
+        + ln(1 + s.follower_count) / 2)::float8 AS score
+FROM candidates c
+JOIN user_stats s ON s.user_id = c.id
+LEFT JOIN second_degree sd ON sd.id = c.id
+LEFT JOIN shared_followers sf ON sf.id = c.id
+CROSS JOIN LATERAL (
+    SELECT count(*) AS n FROM posts p
+    WHERE p.user_id = c.id AND p.deleted_at IS NULL AND p.created_at > @active_since
+) recent
+WHERE c.id != @user_id
+  AND c.id NOT IN (SELECT id FROM following)
+  AND NOT EXISTS (
+      SELECT 1 FROM follow_requests r
+      WHERE r.requester_id = @user_id AND r.target_id = c.id
+  )
+  AND visible_to(@user_id, c.id, @now)
+ORDER BY score DESC, c.id
+LIMIT @page_size;
+
+-- name: GetSuggestedUsers :many
+-- GetSuggestedUsers loads the precomputed suggestions in ids that are
+-- still valid for viewer_id: not followed or requested since, and not
+-- blocked or muted as of now.
+SELECT u.id, u.username, u.display_name, u.avatar_url
+FROM users u
+WHERE u.id = ANY(@ids::uuid[])
+  AND u.id != @viewer_id
+  AND NOT EXISTS (
+      SELECT 1 FROM follows f
+      WHERE f.follower_id = @viewer_id AND f.following_id = u.id
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM follow_requests r
+      WHERE r.requester_id = @viewer_id AND r.target_id = u.id
+  )
+  AND visible_to(@viewer_id, u.id, @now);
@@ -0,0 +1,42 @@
+-- name: UpdateUsername :one
+UPDATE users
+SET username = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateUsernameHistory :exec
+INSERT INTO username_history (user_id, username, changed_at, released_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: LastUsernameChange :one
+SELECT changed_at FROM username_history
+WHERE user_id = $1
+ORDER BY changed_at DESC
+LIMIT 1;
+
+-- name: IsUsernameHeld :one
+-- IsUsernameHeld reports whether a name that looks like username is still
+-- held by someone else's rename.
+SELECT EXISTS (
+    SELECT 1 FROM username_history
+    WHERE username_key(username) = username_key(@username)
+      AND released_at > @now
+      AND user_id <> @user_id
+);
+
+-- name: GetUsernameRedirect :one
+-- GetUsernameRedirect returns the current username of whoever most recently
+-- gave up username, if it still redirects. Like GetUserProfile it finds no
+-- one if that account is deactivated or has blocked viewer_id.
+SELECT u.username
+FROM username_history h
+JOIN users u ON u.id = h.user_id
+WHERE lower(h.username) = lower(@username)
+  AND h.released_at > @now
+  AND u.deactivated_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1 FROM blocks
+      WHERE blocker_id = u.id AND blocked_id = @viewer_id
+  )
+ORDER BY h.changed_at DESC
+LIMIT 1;
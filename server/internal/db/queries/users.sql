@@ -0,0 +1,146 @@
+-- name: CreateUser :one
+INSERT INTO users (username, email, password_hash, display_name)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetUserByUsername :one
+SELECT * FROM users
+WHERE lower(username) = lower($1);
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET display_name = $2, bio = $3, location = $4, pronouns = $5, links = $6, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserAvatar :one
+-- UpdateUserAvatar sets avatar_url and returns the URL it replaced, so the
+-- caller deletes exactly the superseded files even when uploads race.
+UPDATE users u
+SET avatar_url = @avatar_url, updated_at = NOW()
+FROM (SELECT id, avatar_url FROM users WHERE id = @id FOR UPDATE) old
+WHERE u.id = old.id
+RETURNING old.avatar_url AS previous_url;
+
+-- name: UpdateUserBanner :one
+-- UpdateUserBanner is UpdateUserAvatar for banner_url.
+UPDATE users u
+SET banner_url = @banner_url, updated_at = NOW()
+FROM (SELECT id, banner_url FROM users WHERE id = @id FOR UPDATE) old
+WHERE u.id = old.id
+RETURNING old.banner_url AS previous_url;
+
+-- name: UpdateUserPrivacy :one
+-- UpdateUserPrivacy sets is_private and returns the value it replaced.
+UPDATE users u
+SET is_private = @is_private, updated_at = NOW()
+FROM (SELECT id, is_private FROM users WHERE id = @id FOR UPDATE) old
+WHERE u.id = old.id
+RETURNING old.is_private AS was_private;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE id = $1;
+
+-- name: DeactivateUser :exec
+-- DeactivateUser hides an account until it is restored. Deactivating
+-- again keeps the original time.
+UPDATE users
+SET deactivated_at = COALESCE(deactivated_at, @now), updated_at = NOW()
+WHERE id = @id;
+
+-- name: ScheduleUserDeletion :one
+-- ScheduleUserDeletion deactivates an account and schedules it to be
+-- purged at delete_at. An account already scheduled keeps its date, which
+-- is returned.
+UPDATE users
+SET deactivated_at = COALESCE(deactivated_at, @now),
+    deletion_scheduled_at = COALESCE(deletion_scheduled_at, @delete_at),
+    updated_at = NOW()
+WHERE id = @id
+RETURNING deletion_scheduled_at;
+
+-- name: RestoreUser :execrows
+-- RestoreUser reactivates an account and cancels any scheduled deletion.
+-- It matches nothing once the deletion date has passed.
+UPDATE users
+SET deactivated_at = NULL, deletion_scheduled_at = NULL, updated_at = NOW()
+WHERE id = @id
+  AND (deletion_scheduled_at IS NULL OR deletion_scheduled_at > @now);
+
+-- name: ListUsersDueForDeletion :many
+-- ListUsersDueForDeletion pages through accounts whose deletion date has
+-- passed, longest overdue first, resuming after (after_scheduled_at,
+-- after_id) so a run can skip past accounts it failed to purge.
+SELECT id, deletion_scheduled_at FROM users
+WHERE deletion_scheduled_at <= @now
+  AND (sqlc.narg('after_scheduled_at')::timestamptz IS NULL
+       OR (deletion_scheduled_at, id) > (sqlc.narg('after_scheduled_at')::timestamptz, sqlc.narg('after_id')::uuid))
+ORDER BY deletion_scheduled_at, id
+LIMIT @page_size;
+
+-- name: IsAccountActive :one
+-- IsAccountActive reports whether an account exists and is not deactivated.
+SELECT account_active(@id)::boolean AS active;
+
+-- name: LockUserForDeletion :one
+-- LockUserForDeletion returns an account's deletion date and locks its row
+-- until the transaction ends, so it can't be restored while being purged.
+SELECT deletion_scheduled_at FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetUserProfile :one
+-- GetUserProfile returns a user's public columns with the counters kept in
+-- user_stats. It never selects email or password_hash, and finds no one
+-- for a deactivated account or one that has blocked viewer_id. Whoever did
+-- the blocking still sees the profile, so they can undo it.
+SELECT u.id, u.username, u.display_name, u.bio, u.avatar_url, u.banner_url,
+       u.location, u.pronouns, u.links, u.is_private, u.created_at,
+       s.follower_count, s.following_count, s.post_count
+FROM users u
+JOIN user_stats s ON s.user_id = u.id
+WHERE lower(u.username) = lower(@username)
+  AND u.deactivated_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1 FROM blocks
+      WHERE blocker_id = u.id AND blocked_id = @viewer_id
+  );
+
+-- name: GetRelationship :one
+-- GetRelationship describes how the viewer relates to another user.
+SELECT
+    EXISTS (
+        SELECT 1 FROM follows
+        WHERE follower_id = @viewer_id AND following_id = @user_id
+    ) AS is_following,
+    EXISTS (
+        SELECT 1 FROM follows
+        WHERE follower_id = @user_id AND following_id = @viewer_id
+    ) AS follows_you,
+    EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocker_id = @viewer_id AND blocked_id = @user_id
+    ) AS is_blocked,
+    EXISTS (
+        SELECT 1 FROM follow_requests
+        WHERE requester_id = @viewer_id AND target_id = @user_id
+    ) AS is_requested,
+    EXISTS (
+        SELECT 1 FROM mutes
+        WHERE muter_id = @viewer_id AND muted_id = @user_id
+          AND (expires_at IS NULL OR expires_at > @now)
+    ) AS is_muted;
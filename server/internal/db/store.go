@@ -0,0 +1,88 @@
+// Package db is the typed data-access layer. The SQL lives in queries/ and
+// the Go code for it is generated by sqlc (`make sqlc-generate`); only this
+// file is written by hand.
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/retry"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Store is what handlers depend on: every generated query plus
+// transactions. Tests fake it by embedding Querier in a struct and
+// overriding the methods they exercise.
+type Store interface {
+	Querier
+
+	// InTx runs fn in a serializable transaction, committing if it returns
+	// nil. When Postgres aborts the transaction because of a concurrent one,
+	// the whole transaction is retried, so fn may run more than once and
+	// must not have side effects outside it.
+	InTx(ctx context.Context, fn func(Querier) error) error
+}
+
+// txBackoff paces retries of conflicting transactions. Conflicts clear as
+// soon as the other transaction finishes, so retries start quickly and give
+// up after a few attempts rather than queueing behind a hot row.
+var txBackoff = retry.Backoff{
+	Initial:     10 * time.Millisecond,
+	Max:         200 * time.Millisecond,
+	MaxAttempts: 5,
+}
+
+// PoolStore is the Store backed by a connection pool.
+type PoolStore struct {
+	*Queries
+	pool *pgxpool.Pool
+}
+
+var _ Store = (*PoolStore)(nil)
+
+// NewStore returns a Store running queries on pool.
+func NewStore(pool *pgxpool.Pool) *PoolStore {
+	return &PoolStore{Queries: New(pool), pool: pool}
+}
+
+func (s *PoolStore) InTx(ctx context.Context, fn func(Querier) error) error {
+	var permanent error
+	err := retry.Do(ctx, txBackoff, func(ctx context.Context) error {
+		err := pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(tx pgx.Tx) error {
+			return fn(s.Queries.WithTx(tx))
+		})
+		if err != nil && !retryable(err) {
+			// Stop retrying; the error is returned as is below.
+			permanent = err
+			return nil
+		}
+		return err
+	}, nil)
+	if permanent != nil {
+		return permanent
+	}
+	return err
+}
+
+// retryable reports whether err aborted a transaction that may succeed if
+// run again: a serialization failure or a deadlock.
+func retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == "40001" || pgErr.Code == "40P01"
+}
+
+// IsBlocked reports whether err is Postgres refusing an interaction between
+// users on either side of a block. The reject_blocked trigger raises it
+// for follows, follow requests, likes, comments and notifications, so every
+// write path fails the same way.
+func IsBlocked(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23514" && pgErr.ConstraintName == "blocked"
+}
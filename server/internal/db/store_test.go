@@ -0,0 +1,264 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/testutil"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) { os.Exit(testutil.Main(m)) }
+
+func createUser(t *testing.T, q db.Querier, username string) db.User {
+	t.Helper()
+	u, err := q.CreateUser(context.Background(), db.CreateUserParams{
+		Username:     username,
+		Email:        username + "@example.com",
+		PasswordHash: "hash",
+		DisplayName:  username,
+	})
+	require.NoError(t, err)
+	return u
+}
+
+func TestQueries(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewStore(testutil.DB(t))
+	alice := createUser(t, store, "alice")
+	bob := createUser(t, store, "bob")
+
+	t.Run("Users", func(t *testing.T) {
+		got, err := store.GetUserByUsername(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, alice.ID, got.ID)
+		assert.Nil(t, got.Bio)
+		assert.Equal(t, "user", got.Role)
+
+		bio := "hello"
+		got, err = store.UpdateUserProfile(ctx, db.UpdateUserProfileParams{ID: alice.ID, DisplayName: "Alice", Bio: &bio, Links: []string{}})
+		require.NoError(t, err)
+		assert.Equal(t, "Alice", got.DisplayName)
+		assert.Equal(t, &bio, got.Bio)
+
+		_, err = store.GetUserByID(ctx, uuid.New())
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("Follows are idempotent", func(t *testing.T) {
+		follow := db.CreateFollowParams{FollowerID: bob.ID, FollowingID: alice.ID}
+		n, err := store.CreateFollow(ctx, follow)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+		n, err = store.CreateFollow(ctx, follow)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		following, err := store.IsFollowing(ctx, db.IsFollowingParams{FollowerID: bob.ID, FollowingID: alice.ID})
+		require.NoError(t, err)
+		assert.True(t, following)
+
+		followers, err := store.ListFollowers(ctx, db.ListFollowersParams{UserID: alice.ID, PageSize: 10})
+		require.NoError(t, err)
+		require.Len(t, followers, 1)
+		assert.Equal(t, "bob", followers[0].Username)
+	})
+
+	t.Run("Feed pages by cursor", func(t *testing.T) {
+		for i := range 5 {
+			_, err := store.CreatePost(ctx, db.CreatePostParams{UserID: alice.ID, Content: fmt.Sprintf("post %d", i), MediaUrls: []string{}})
+			require.NoError(t, err)
+		}
+
+		var seen []string
+		params := db.ListFeedParams{ViewerID: bob.ID, Now: time.Now(), PageSize: 2}
+		for {
+			page, err := store.ListFeed(ctx, params)
+			require.NoError(t, err)
+			if len(page) == 0 {
+				break
+			}
+			for _, p := range page {
+				seen = append(seen, p.Content)
+			}
+			last := page[len(page)-1]
+			params.BeforeCreatedAt, params.BeforeID = &last.CreatedAt, &last.ID
+		}
+		assert.Len(t, seen, 5)
+		assert.Equal(t, "post 4", seen[0])
+	})
+
+	t.Run("Threaded comments", func(t *testing.T) {
+		post, err := store.CreatePost(ctx, db.CreatePostParams{UserID: alice.ID, Content: "thread", MediaUrls: []string{}})
+		require.NoError(t, err)
+		parent, err := store.CreateComment(ctx, db.CreateCommentParams{PostID: post.ID, UserID: &bob.ID, Content: "first"})
+		require.NoError(t, err)
+		_, err = store.CreateComment(ctx, db.CreateCommentParams{PostID: post.ID, UserID: &alice.ID, ParentID: &parent.ID, Content: "reply"})
+		require.NoError(t, err)
+
+		comments, err := store.ListPostComments(ctx, db.ListPostCommentsParams{PostID: post.ID, PageSize: 10})
+		require.NoError(t, err)
+		require.Len(t, comments, 2)
+		assert.Nil(t, comments[0].ParentID)
+		assert.Equal(t, parent.ID, *comments[1].ParentID)
+	})
+
+	t.Run("Notifications", func(t *testing.T) {
+		_, err := store.CreateNotification(ctx, db.CreateNotificationParams{
+			RecipientID: alice.ID, ActorID: bob.ID, Type: "follow", EntityID: bob.ID, EntityType: "user",
+		})
+		require.NoError(t, err)
+
+		unread, err := store.CountUnreadNotifications(ctx, db.CountUnreadNotificationsParams{RecipientID: alice.ID, Now: time.Now()})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, unread)
+
+		n, err := store.MarkAllNotificationsRead(ctx, alice.ID)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+	})
+
+	t.Run("User stats follow their source tables", func(t *testing.T) {
+		carol := createUser(t, store, "carol")
+		stats := func() db.GetUserProfileRow {
+			p, err := store.GetUserProfile(ctx, db.GetUserProfileParams{Username: "carol"})
+			require.NoError(t, err)
+			return p
+		}
+		assert.Zero(t, stats().FollowerCount)
+
+		follow := db.CreateFollowParams{FollowerID: alice.ID, FollowingID: carol.ID}
+		_, err := store.CreateFollow(ctx, follow)
+		require.NoError(t, err)
+		_, err = store.CreateFollow(ctx, follow)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, stats().FollowerCount, "repeated follows count once")
+
+		post, err := store.CreatePost(ctx, db.CreatePostParams{UserID: carol.ID, Content: "hi", MediaUrls: []string{}})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, stats().PostCount)
+		_, err = store.SoftDeletePost(ctx, db.SoftDeletePostParams{ID: post.ID, UserID: carol.ID})
+		require.NoError(t, err)
+		assert.Zero(t, stats().PostCount)
+
+		_, err = store.DeleteFollow(ctx, db.DeleteFollowParams{FollowerID: alice.ID, FollowingID: carol.ID})
+		require.NoError(t, err)
+		assert.Zero(t, stats().FollowerCount)
+
+		rel, err := store.GetRelationship(ctx, db.GetRelationshipParams{ViewerID: bob.ID, UserID: alice.ID})
+		require.NoError(t, err)
+		assert.True(t, rel.IsFollowing)
+		assert.False(t, rel.FollowsYou)
+	})
+
+	t.Run("Private posts are hidden from non-followers", func(t *testing.T) {
+		dave := createUser(t, store, "dave")
+		_, err := store.UpdateUserPrivacy(ctx, db.UpdateUserPrivacyParams{ID: dave.ID, IsPrivate: true})
+		require.NoError(t, err)
+		post, err := store.CreatePost(ctx, db.CreatePostParams{UserID: dave.ID, Content: "secret", MediaUrls: []string{}})
+		require.NoError(t, err)
+		comment, err := store.CreateComment(ctx, db.CreateCommentParams{PostID: post.ID, UserID: &dave.ID, Content: "psst"})
+		require.NoError(t, err)
+
+		visible := func(viewer uuid.UUID) bool {
+			_, err := store.GetPost(ctx, db.GetPostParams{ID: post.ID, ViewerID: viewer})
+			if errors.Is(err, pgx.ErrNoRows) {
+				_, err = store.GetComment(ctx, db.GetCommentParams{ID: comment.ID, ViewerID: viewer})
+				assert.ErrorIs(t, err, pgx.ErrNoRows)
+				posts, err := store.ListUserPosts(ctx, db.ListUserPostsParams{UserID: dave.ID, ViewerID: viewer, PageSize: 10})
+				require.NoError(t, err)
+				assert.Empty(t, posts)
+				comments, err := store.ListPostComments(ctx, db.ListPostCommentsParams{PostID: post.ID, ViewerID: viewer, PageSize: 10})
+				require.NoError(t, err)
+				assert.Empty(t, comments)
+				return false
+			}
+			require.NoError(t, err)
+			return true
+		}
+		assert.True(t, visible(dave.ID), "owners see their own posts")
+		assert.False(t, visible(uuid.Nil), "anonymous viewers")
+		assert.False(t, visible(bob.ID))
+
+		_, err = store.CreateFollowRequest(ctx, db.CreateFollowRequestParams{RequesterID: bob.ID, TargetID: dave.ID})
+		require.NoError(t, err)
+		assert.False(t, visible(bob.ID), "a pending request grants nothing")
+		rel, err := store.GetRelationship(ctx, db.GetRelationshipParams{ViewerID: bob.ID, UserID: dave.ID})
+		require.NoError(t, err)
+		assert.True(t, rel.IsRequested)
+
+		wasPrivate, err := store.UpdateUserPrivacy(ctx, db.UpdateUserPrivacyParams{ID: dave.ID, IsPrivate: false})
+		require.NoError(t, err)
+		assert.True(t, wasPrivate)
+		n, err := store.AcceptAllFollowRequests(ctx, dave.ID)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+		_, err = store.UpdateUserPrivacy(ctx, db.UpdateUserPrivacyParams{ID: dave.ID, IsPrivate: true})
+		require.NoError(t, err)
+		assert.True(t, visible(bob.ID), "accepted requesters follow")
+	})
+}
+
+func TestInTx(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewStore(testutil.DB(t))
+
+	t.Run("Rolls back on error", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		err := store.InTx(ctx, func(q db.Querier) error {
+			createUser(t, q, "rolledback")
+			return errBoom
+		})
+		assert.ErrorIs(t, err, errBoom)
+
+		_, err = store.GetUserByUsername(ctx, "rolledback")
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("Retries serialization failures", func(t *testing.T) {
+		attempts := 0
+		err := store.InTx(ctx, func(q db.Querier) error {
+			attempts++
+			createUser(t, q, "retried")
+			if attempts < 3 {
+				return &pgconn.PgError{Code: "40001"}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+
+		_, err = store.GetUserByUsername(ctx, "retried")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Concurrent conflicting transactions all commit", func(t *testing.T) {
+		owner := createUser(t, store, "counter")
+		errs := make(chan error, 3)
+		for i := range 3 {
+			go func() {
+				errs <- store.InTx(ctx, func(q db.Querier) error {
+					n, err := q.CountUserPosts(ctx, owner.ID)
+					if err != nil {
+						return err
+					}
+					time.Sleep(10 * time.Millisecond)
+					_, err = q.CreatePost(ctx, db.CreatePostParams{UserID: owner.ID, Content: fmt.Sprintf("%d-%d", i, n), MediaUrls: []string{}})
+					return err
+				})
+			}()
+		}
+		for range 3 {
+			assert.NoError(t, <-errs)
+		}
+	})
+}
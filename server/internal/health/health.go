@@ -0,0 +1,228 @@
+// Package health runs dependency checks for the liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/httpx"
+	"golang.org/x/sync/singleflight"
+)
+
+// Overall and per-component statuses reported by the probes.
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded"
+	StatusDraining = "draining"
+)
+
+// CheckFunc reports whether a dependency is usable. It must honour ctx.
+type CheckFunc func(ctx context.Context) error
+
+// ComponentStatus is the result of a single dependency check.
+type ComponentStatus struct {
+	Status    string  `json:"status"`
+	Optional  bool    `json:"optional,omitempty"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the readiness response body.
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+	CheckedAt  time.Time                  `json:"checked_at"`
+}
+
+type component struct {
+	name     string
+	check    CheckFunc
+	optional bool
+}
+
+// Checker runs the registered checks in parallel, each under its own timeout,
+// and caches the combined report for a short while so probes hitting every
+// replica every second don't hammer the dependencies.
+type Checker struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	components []component
+	draining   atomic.Bool
+
+	refresh  singleflight.Group
+	mu       sync.Mutex
+	cached   *Report
+	cachedAt time.Time
+}
+
+// NewChecker returns a Checker with the given per-check timeout and cache TTL.
+func NewChecker(timeout, cacheTTL time.Duration) *Checker {
+	return &Checker{timeout: timeout, cacheTTL: cacheTTL}
+}
+
+// Register adds a named dependency check. It must be called before the
+// checker starts serving probes.
+func (c *Checker) Register(name string, check CheckFunc) {
+	c.components = append(c.components, component{name: name, check: check})
+}
+
+// RegisterOptional adds a dependency the server can run without. While it is
+// down the server reports itself degraded but stays ready; routes that need
+// it should be wrapped with Require.
+func (c *Checker) RegisterOptional(name string, check CheckFunc) {
+	c.components = append(c.components, component{name: name, check: check, optional: true})
+}
+
+// Available reports whether the named component passed its latest check.
+func (c *Checker) Available(ctx context.Context, name string) bool {
+	status, ok := c.Check(ctx).Components[name]
+	return ok && status.Status == StatusUp
+}
+
+// Require returns a middleware that answers 503 while the named component is
+// down, so a dependency outage only disables the routes that need it.
+func (c *Checker) Require(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !c.Available(r.Context(), name) {
+				w.Header().Set("Retry-After", "30")
+				httpx.WriteError(w, http.StatusServiceUnavailable, "dependency_unavailable",
+					name+" is temporarily unavailable")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SetDraining marks the server as shutting down. From then on readiness fails
+// so load balancers stop routing new requests here.
+func (c *Checker) SetDraining() {
+	c.draining.Store(true)
+}
+
+// Check returns the current report, running the checks if the cached one is
+// older than the cache TTL. Concurrent callers share a single run, which is
+// detached from ctx so a caller giving up early can't get every component
+// cached as down.
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.Lock()
+	cached, fresh := c.cached, c.cached != nil && time.Since(c.cachedAt) < c.cacheTTL
+	c.mu.Unlock()
+
+	if !fresh {
+		v, _, _ := c.refresh.Do("check", func() (any, error) {
+			report := c.run(context.WithoutCancel(ctx))
+			c.mu.Lock()
+			c.cached, c.cachedAt = &report, time.Now()
+			c.mu.Unlock()
+			return &report, nil
+		})
+		cached = v.(*Report)
+	}
+
+	report := *cached
+	if c.draining.Load() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context) Report {
+	results := make([]ComponentStatus, len(c.components))
+
+	var wg sync.WaitGroup
+	for i, comp := range c.components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.runOne(ctx, comp.check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:     StatusUp,
+		Components: make(map[string]ComponentStatus, len(c.components)),
+		CheckedAt:  time.Now().UTC(),
+	}
+	for i, comp := range c.components {
+		results[i].Optional = comp.optional
+		report.Components[comp.name] = results[i]
+		if results[i].Status == StatusUp {
+			continue
+		}
+		if !comp.optional {
+			report.Status = StatusDown
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (c *Checker) runOne(ctx context.Context, check CheckFunc) (status ComponentStatus) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		status.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+	}()
+
+	// Run the check in its own goroutine so one that ignores ctx still
+	// cannot hold the probe past its timeout.
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", c.timeout)
+		}
+		return ComponentStatus{Status: StatusDown, Error: err.Error()}
+	}
+	return ComponentStatus{Status: StatusUp}
+}
+
+// Liveness handles GET /livez. It only reports that the process is serving
+// requests; dependency failures must not get a healthy pod restarted.
+func (c *Checker) Liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	httpx.WriteJSON(w, http.StatusOK, map[string]string{"status": StatusUp})
+}
+
+// Readiness handles GET /readyz. It returns 200 when every required
+// dependency is up, even if optional ones are not, and 503 when a required
+// one is down or the server is draining.
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+
+	status := http.StatusOK
+	if report.Status == StatusDown || report.Status == StatusDraining {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	httpx.WriteJSON(w, status, report)
+}
@@ -0,0 +1,229 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker(t *testing.T) {
+	t.Run("All components up", func(t *testing.T) {
+		c := NewChecker(time.Second, 0)
+		c.Register("postgres", func(ctx context.Context) error { return nil })
+		c.Register("redis", func(ctx context.Context) error { return nil })
+
+		report := c.Check(context.Background())
+
+		assert.Equal(t, StatusUp, report.Status)
+		assert.Equal(t, StatusUp, report.Components["postgres"].Status)
+		assert.Equal(t, StatusUp, report.Components["redis"].Status)
+	})
+
+	t.Run("One component down", func(t *testing.T) {
+		c := NewChecker(time.Second, 0)
+		c.Register("postgres", func(ctx context.Context) error { return nil })
+		c.Register("minio", func(ctx context.Context) error { return errors.New("connection refused") })
+
+		report := c.Check(context.Background())
+
+		assert.Equal(t, StatusDown, report.Status)
+		assert.Equal(t, StatusDown, report.Components["minio"].Status)
+		assert.Equal(t, "connection refused", report.Components["minio"].Error)
+	})
+
+	t.Run("Optional component down means degraded", func(t *testing.T) {
+		c := NewChecker(time.Second, 0)
+		c.Register("postgres", func(ctx context.Context) error { return nil })
+		c.RegisterOptional("minio", func(ctx context.Context) error { return errors.New("connection refused") })
+
+		report := c.Check(context.Background())
+
+		assert.Equal(t, StatusDegraded, report.Status)
+		assert.True(t, report.Components["minio"].Optional)
+		assert.False(t, c.Available(context.Background(), "minio"))
+		assert.True(t, c.Available(context.Background(), "postgres"))
+	})
+
+	t.Run("Checks run in parallel under a timeout", func(t *testing.T) {
+		c := NewChecker(50*time.Millisecond, 0)
+		for _, name := range []string{"a", "b", "c"} {
+			c.Register(name, func(ctx context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			})
+		}
+
+		start := time.Now()
+		report := c.Check(context.Background())
+
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		assert.Equal(t, StatusDown, report.Status)
+		assert.Contains(t, report.Components["a"].Error, "timed out")
+		assert.GreaterOrEqual(t, report.Components["a"].LatencyMS, 50.0)
+	})
+
+	t.Run("Panicking check is reported as down", func(t *testing.T) {
+		c := NewChecker(time.Second, 0)
+		c.Register("broken", func(ctx context.Context) error { panic("boom") })
+
+		report := c.Check(context.Background())
+
+		assert.Equal(t, StatusDown, report.Components["broken"].Status)
+		assert.Contains(t, report.Components["broken"].Error, "boom")
+	})
+
+	t.Run("Results are cached", func(t *testing.T) {
+		var calls atomic.Int32
+		c := NewChecker(time.Second, time.Minute)
+		c.Register("redis", func(ctx context.Context) error {
+			calls.Add(1)
+			return nil
+		})
+
+		c.Check(context.Background())
+		c.Check(context.Background())
+
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Cancelled callers don't cache an outage", func(t *testing.T) {
+		c := NewChecker(time.Second, time.Minute)
+		c.Register("redis", func(ctx context.Context) error { return ctx.Err() })
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		report := c.Check(ctx)
+
+		assert.Equal(t, StatusUp, report.Status)
+		assert.True(t, c.Available(context.Background(), "redis"))
+	})
+
+	t.Run("Concurrent callers share one run", func(t *testing.T) {
+		var calls atomic.Int32
+		release := make(chan struct{})
+		c := NewChecker(time.Second, time.Minute)
+		c.Register("redis", func(ctx context.Context) error {
+			calls.Add(1)
+			<-release
+			return nil
+		})
+
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.Equal(t, StatusUp, c.Check(context.Background()).Status)
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
+
+func TestProbeHandlers(t *testing.T) {
+	var dbErr atomic.Value
+	dbErr.Store(errors.New(""))
+	c := NewChecker(time.Second, 0)
+	c.Register("postgres", func(ctx context.Context) error {
+		if err := dbErr.Load().(error); err.Error() != "" {
+			return err
+		}
+		return nil
+	})
+
+	t.Run("Liveness ignores dependencies", func(t *testing.T) {
+		dbErr.Store(errors.New("down"))
+		w := httptest.NewRecorder()
+
+		c.Liveness(w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status":"up"}`, w.Body.String())
+	})
+
+	t.Run("Readiness reports failures with 503", func(t *testing.T) {
+		dbErr.Store(errors.New("down"))
+		w := httptest.NewRecorder()
+
+		c.Readiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var report Report
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		assert.Equal(t, StatusDown, report.Status)
+		assert.Equal(t, "down", report.Components["postgres"].Error)
+	})
+
+	t.Run("Readiness succeeds when dependencies are up", func(t *testing.T) {
+		dbErr.Store(errors.New(""))
+		w := httptest.NewRecorder()
+
+		c.Readiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Draining server is not ready", func(t *testing.T) {
+		c.SetDraining()
+		w := httptest.NewRecorder()
+
+		c.Readiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), `"status":"draining"`)
+	})
+}
+
+func TestRequire(t *testing.T) {
+	var minioErr atomic.Bool
+	c := NewChecker(time.Second, 0)
+	c.Register("postgres", func(ctx context.Context) error { return nil })
+	c.RegisterOptional("minio", func(ctx context.Context) error {
+		if minioErr.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	media := c.Require("minio")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("uploaded"))
+	}))
+
+	t.Run("Route disabled and server still ready while dependency is down", func(t *testing.T) {
+		minioErr.Store(true)
+
+		w := httptest.NewRecorder()
+		media.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/media", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "dependency_unavailable")
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+		w = httptest.NewRecorder()
+		c.Readiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"status":"degraded"`)
+	})
+
+	t.Run("Route recovers with the dependency", func(t *testing.T) {
+		minioErr.Store(false)
+
+		w := httptest.NewRecorder()
+		media.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/media", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "uploaded", w.Body.String())
+	})
+}
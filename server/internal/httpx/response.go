@@ -0,0 +1,68 @@
+// Package httpx holds the small helpers shared by handlers and middleware for
+// writing JSON responses.
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrorResponse is the JSON body returned for every structured API error.
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// WriteJSON encodes v as the JSON response body with the given status code.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// WriteError writes a structured error. code is a stable, machine-readable
+// identifier such as "request_too_large"; message is for humans.
+func WriteError(w http.ResponseWriter, status int, code, message string) {
+	WriteJSON(w, status, ErrorResponse{Error: code, Message: message})
+}
+
+// WriteTooLarge writes the structured 413 returned when a request body
+// exceeds its limit.
+func WriteTooLarge(w http.ResponseWriter, limit int64) {
+	WriteError(w, http.StatusRequestEntityTooLarge, "request_too_large",
+		fmt.Sprintf("request body must not exceed %d bytes", limit))
+}
+
+// DecodeJSON decodes the request body into dst. On failure it writes a
+// structured 400, or a 413 when the body hit the limit set by
+// middleware.MaxBytes, and returns false.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	return decodeJSON(w, r, dst, false)
+}
+
+// DecodeOptionalJSON is DecodeJSON for bodies that may be left out: an empty
+// body leaves dst as it is.
+func DecodeOptionalJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	return decodeJSON(w, r, dst, true)
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any, optional bool) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		if optional && errors.Is(err, io.EOF) {
+			return true
+		}
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			WriteTooLarge(w, maxErr.Limit)
+			return false
+		}
+		WriteError(w, http.StatusBadRequest, "invalid_json", "request body must be valid JSON")
+		return false
+	}
+	return true
+}
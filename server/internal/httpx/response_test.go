@@ -0,0 +1,73 @@
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteError(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	WriteError(w, http.StatusRequestEntityTooLarge, "request_too_large", "too big")
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "request_too_large", resp.Error)
+	assert.Equal(t, "too big", resp.Message)
+}
+
+func TestDecodeJSON(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+
+	t.Run("Valid body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"alice"}`))
+		w := httptest.NewRecorder()
+
+		var p payload
+		assert.True(t, DecodeJSON(w, req, &p))
+		assert.Equal(t, "alice", p.Name)
+	})
+
+	t.Run("Malformed body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":`))
+		w := httptest.NewRecorder()
+
+		var p payload
+		assert.False(t, DecodeJSON(w, req, &p))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Body over the limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"a very long name"}`))
+		w := httptest.NewRecorder()
+		req.Body = http.MaxBytesReader(w, req.Body, 8)
+
+		var p payload
+		assert.False(t, DecodeJSON(w, req, &p))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), "request_too_large")
+	})
+
+	t.Run("Optional body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		w := httptest.NewRecorder()
+
+		var p payload
+		assert.False(t, DecodeJSON(w, req, &p))
+		assert.True(t, DecodeOptionalJSON(httptest.NewRecorder(), req, &p))
+
+		req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":`))
+		assert.False(t, DecodeOptionalJSON(httptest.NewRecorder(), req, &p))
+	})
+}
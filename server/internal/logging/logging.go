@@ -0,0 +1,158 @@
+// Package logging builds the application's slog pipeline: output format,
+// global and per-module levels, redaction of secrets and PII, and loggers
+// scoped to a single request.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ModuleKey is the attribute that names the subsystem a logger belongs to.
+// Per-module levels are keyed on its value, e.g. logger.With(ModuleKey, "auth").
+const ModuleKey = "module"
+
+// Config controls how New builds the logger.
+type Config struct {
+	// Format is "json" (the default) or "text".
+	Format string
+	// Level is the minimum level for modules without their own entry.
+	Level slog.Level
+	// ModuleLevels overrides Level for specific modules.
+	ModuleLevels map[string]slog.Level
+	// Output defaults to os.Stdout.
+	Output io.Writer
+}
+
+// New returns a logger that redacts sensitive attributes and filters records
+// by the level of the module they were logged from.
+func New(cfg Config) *slog.Logger {
+	out := cfg.Output
+	if out == nil {
+		out = os.Stdout
+	}
+
+	opts := &slog.HandlerOptions{
+		// Filtering happens in moduleHandler; the inner handler accepts everything.
+		Level:       slog.Level(-16),
+		ReplaceAttr: Redact,
+	}
+
+	var inner slog.Handler
+	if strings.EqualFold(cfg.Format, "text") {
+		inner = slog.NewTextHandler(out, opts)
+	} else {
+		inner = slog.NewJSONHandler(out, opts)
+	}
+
+	h := &moduleHandler{inner: inner, levels: new(atomic.Pointer[levels])}
+	h.levels.Store(&levels{level: cfg.Level, modules: cfg.ModuleLevels})
+	return slog.New(h)
+}
+
+// SetLevels changes the global and per-module levels of a logger built by New,
+// including every logger derived from it with With. It reports false if
+// logger was not built by New.
+func SetLevels(logger *slog.Logger, level slog.Level, modules map[string]slog.Level) bool {
+	h, ok := logger.Handler().(*moduleHandler)
+	if !ok {
+		return false
+	}
+	h.levels.Store(&levels{level: level, modules: modules})
+	return true
+}
+
+type levels struct {
+	level   slog.Level
+	modules map[string]slog.Level
+}
+
+// moduleHandler applies a per-module minimum level. The module is picked up
+// from a ModuleKey attribute added with Logger.With. The levels are shared
+// with every clone so SetLevels reaches loggers that were already derived.
+type moduleHandler struct {
+	inner  slog.Handler
+	levels *atomic.Pointer[levels]
+	module string
+}
+
+func (h *moduleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	lv := h.levels.Load()
+	min := lv.level
+	if l, ok := lv.modules[h.module]; ok && h.module != "" {
+		min = l
+	}
+	return level >= min
+}
+
+func (h *moduleHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *moduleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.inner = h.inner.WithAttrs(attrs)
+	for _, a := range attrs {
+		if a.Key == ModuleKey {
+			clone.module = a.Value.String()
+		}
+	}
+	return &clone
+}
+
+func (h *moduleHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.inner = h.inner.WithGroup(name)
+	return &clone
+}
+
+type contextKey struct{}
+
+// scope holds the logger for one request. It is shared by pointer so
+// attributes added deep in the handler chain (such as the user ID) are also
+// seen by the middleware that writes the access log line.
+type scope struct {
+	mu     sync.Mutex
+	logger *slog.Logger
+}
+
+// WithLogger returns a copy of ctx carrying logger as the request-scoped logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, &scope{logger: logger})
+}
+
+// AddAttrs attaches attributes to the request-scoped logger in ctx. It is a
+// no-op when ctx has no request scope.
+func AddAttrs(ctx context.Context, args ...any) {
+	s, ok := ctx.Value(contextKey{}).(*scope)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	s.logger = s.logger.With(args...)
+	s.mu.Unlock()
+}
+
+// FromContext returns the request-scoped logger stored in ctx, or the default
+// logger. Once chi has routed the request, the route pattern is attached too.
+func FromContext(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+	if s, ok := ctx.Value(contextKey{}).(*scope); ok {
+		s.mu.Lock()
+		logger = s.logger
+		s.mu.Unlock()
+	}
+	if rctx := chi.RouteContext(ctx); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			logger = logger.With(slog.String("route", pattern))
+		}
+	}
+	return logger
+}
@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("JSON output at the configured level", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := New(Config{Level: slog.LevelInfo, Output: &buf})
+
+		logger.Debug("hidden")
+		logger.Info("shown", slog.String("key", "value"))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 1)
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+		assert.Equal(t, "shown", entry["msg"])
+		assert.Equal(t, "value", entry["key"])
+	})
+
+	t.Run("Text output", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := New(Config{Format: "text", Output: &buf})
+
+		logger.Info("hello")
+
+		assert.Contains(t, buf.String(), "msg=hello")
+	})
+
+	t.Run("Per-module levels", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := New(Config{
+			Level:        slog.LevelInfo,
+			ModuleLevels: map[string]slog.Level{"auth": slog.LevelDebug, "http": slog.LevelWarn},
+			Output:       &buf,
+		})
+
+		logger.With(ModuleKey, "auth").Debug("auth debug")
+		logger.With(ModuleKey, "http").Info("http info")
+		logger.With(ModuleKey, "http").Warn("http warn")
+		logger.With(ModuleKey, "db").Debug("db debug")
+
+		out := buf.String()
+		assert.Contains(t, out, "auth debug")
+		assert.NotContains(t, out, "http info")
+		assert.Contains(t, out, "http warn")
+		assert.NotContains(t, out, "db debug")
+	})
+
+	t.Run("Redacts through the pipeline", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := New(Config{Output: &buf})
+
+		logger.Info("login", slog.String("email", "alice@example.com"), slog.Group("req", slog.String("Authorization", "Bearer abc")))
+
+		assert.NotContains(t, buf.String(), "alice@example.com")
+		assert.NotContains(t, buf.String(), "abc")
+		assert.Contains(t, buf.String(), Redacted)
+	})
+
+	t.Run("SetLevels reaches derived loggers", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := New(Config{Level: slog.LevelInfo, Output: &buf})
+		auth := logger.With(ModuleKey, "auth")
+
+		auth.Debug("before")
+		require.True(t, SetLevels(logger, slog.LevelWarn, map[string]slog.Level{"auth": slog.LevelDebug}))
+		auth.Debug("after")
+		logger.Info("global info")
+
+		out := buf.String()
+		assert.NotContains(t, out, "before")
+		assert.Contains(t, out, "after")
+		assert.NotContains(t, out, "global info")
+
+		assert.False(t, SetLevels(slog.New(slog.NewTextHandler(&buf, nil)), slog.LevelDebug, nil))
+	})
+}
+
+func TestRequestScope(t *testing.T) {
+	var buf bytes.Buffer
+	base := New(Config{Output: &buf})
+
+	t.Run("Falls back to the default logger", func(t *testing.T) {
+		assert.Equal(t, slog.Default(), FromContext(context.Background()))
+	})
+
+	t.Run("Attributes added later are shared", func(t *testing.T) {
+		buf.Reset()
+		ctx := WithLogger(context.Background(), base.With(slog.String("request_id", "req-1")))
+		inner := context.WithValue(ctx, struct{}{}, "derived")
+
+		AddAttrs(inner, slog.String("user_id", "user-1"))
+		FromContext(ctx).Info("done")
+
+		assert.Contains(t, buf.String(), `"request_id":"req-1"`)
+		assert.Contains(t, buf.String(), `"user_id":"user-1"`)
+	})
+
+	t.Run("Includes the chi route pattern", func(t *testing.T) {
+		buf.Reset()
+		r := chi.NewRouter()
+		r.Get("/users/{username}", func(w http.ResponseWriter, r *http.Request) {
+			FromContext(r.Context()).Info("profile")
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/users/alice", nil)
+		req = req.WithContext(WithLogger(req.Context(), base))
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Contains(t, buf.String(), `"route":"/users/{username}"`)
+	})
+}
@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Redacted replaces the value of every sensitive attribute.
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys (normalised to lower case with '-' mapped
+// to '_') whose values must never reach the logs.
+var sensitiveKeys = map[string]bool{
+	"authorization":       true,
+	"cookie":              true,
+	"set_cookie":          true,
+	"password":            true,
+	"password_hash":       true,
+	"token":               true,
+	"access_token":        true,
+	"refresh_token":       true,
+	"secret":              true,
+	"api_key":             true,
+	"private_key":         true,
+	"email":               true,
+	"phone":               true,
+	"x_csrf_token":        true,
+	"proxy_authorization": true,
+}
+
+// sensitiveHeaders are redacted when a whole http.Header is logged.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization", "X-Csrf-Token"}
+
+// Redact is a slog ReplaceAttr function that masks credentials and PII. It
+// matches attribute keys, logged http.Header values and bearer tokens that
+// end up inside ordinary strings.
+func Redact(_ []string, a slog.Attr) slog.Attr {
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if s := a.Value.String(); containsBearer(s) {
+			return slog.String(a.Key, redactBearer(s))
+		}
+	case slog.KindAny:
+		if h, ok := a.Value.Any().(http.Header); ok {
+			return slog.Any(a.Key, redactHeader(h))
+		}
+	}
+	return a
+}
+
+func isSensitiveKey(key string) bool {
+	return sensitiveKeys[strings.ReplaceAll(strings.ToLower(key), "-", "_")]
+}
+
+func redactHeader(h http.Header) http.Header {
+	clone := h.Clone()
+	for _, name := range sensitiveHeaders {
+		if _, ok := clone[name]; ok {
+			clone[name] = []string{Redacted}
+		}
+	}
+	return clone
+}
+
+func containsBearer(s string) bool {
+	return strings.Contains(strings.ToLower(s), "bearer ")
+}
+
+// redactBearer masks the token that follows each "Bearer " in s.
+func redactBearer(s string) string {
+	lower := strings.ToLower(s)
+	var b strings.Builder
+	for {
+		i := strings.Index(lower, "bearer ")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		start := i + len("bearer ")
+		end := start
+		for end < len(s) && s[end] != ' ' && s[end] != ',' && s[end] != '"' {
+			end++
+		}
+		b.WriteString(s[:start])
+		b.WriteString(Redacted)
+		s, lower = s[end:], lower[end:]
+	}
+}
@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	t.Run("Sensitive keys", func(t *testing.T) {
+		for _, key := range []string{"authorization", "Cookie", "set-cookie", "password", "refresh_token", "email", "Private-Key"} {
+			got := Redact(nil, slog.String(key, "secret-value"))
+			assert.Equal(t, Redacted, got.Value.String(), key)
+		}
+	})
+
+	t.Run("Ordinary keys are kept", func(t *testing.T) {
+		got := Redact(nil, slog.String("path", "/api/v1/users"))
+		assert.Equal(t, "/api/v1/users", got.Value.String())
+	})
+
+	t.Run("Bearer tokens inside strings", func(t *testing.T) {
+		got := Redact(nil, slog.String("error", `upstream said "Bearer eyJhbGci.payload.sig" was bad`))
+		assert.Equal(t, `upstream said "Bearer [REDACTED]" was bad`, got.Value.String())
+	})
+
+	t.Run("HTTP headers", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("Authorization", "Bearer token")
+		h.Set("Cookie", "refresh_token=abc")
+		h.Set("Accept", "application/json")
+
+		got := Redact(nil, slog.Any("headers", h)).Value.Any().(http.Header)
+
+		assert.Equal(t, Redacted, got.Get("Authorization"))
+		assert.Equal(t, Redacted, got.Get("Cookie"))
+		assert.Equal(t, "application/json", got.Get("Accept"))
+		assert.Equal(t, "Bearer token", h.Get("Authorization"), "original header must not be modified")
+	})
+}
@@ -2,10 +2,13 @@ package middleware
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 	"strings"
 
 	"github.com/hrutav-modha/social-media-app/server/internal/auth"
+	"github.com/hrutav-modha/social-media-app/server/internal/httpx"
+	"github.com/hrutav-modha/social-media-app/server/internal/logging"
 )
 
 const (
@@ -13,34 +16,95 @@ const (
 	UserIDKey contextKey = "userID"
 )
 
-// Auth is a middleware that extracts the Bearer token from the Authorization header,
-// validates the JWT, and attaches the userID to the request context.
+// Auth returns a middleware that extracts the Bearer token from the Authorization header,
+// validates the JWT with tokens, and attaches the userID to the request context.
 // It returns a 401 Unauthorized response if the token is missing or invalid.
-func Auth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Unauthorized: missing authorization header", http.StatusUnauthorized)
-			return
-		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Unauthorized: invalid authorization header format", http.StatusUnauthorized)
-			return
-		}
-
-		tokenString := parts[1]
-		userID, err := auth.ValidateAccessToken(tokenString)
-		if err != nil {
-			http.Error(w, "Unauthorized: invalid or expired token", http.StatusUnauthorized)
-			return
-		}
-
-		// Attach userID to context
-		ctx := context.WithValue(r.Context(), UserIDKey, userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+func Auth(tokens *auth.TokenIssuer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				http.Error(w, "Unauthorized: missing authorization header", http.StatusUnauthorized)
+				return
+			}
+
+			userID, problem := validateBearer(tokens, authHeader)
+			if problem != "" {
+				http.Error(w, "Unauthorized: "+problem, http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, withUserID(r, userID))
+		})
+	}
+}
+
+// OptionalAuth is Auth for routes that anonymous clients may also use, such
+// as public profiles. A request without an Authorization header passes
+// through with no userID; a bad header or token is still a 401, so a client
+// whose token expired is told so rather than quietly served the anonymous
+// view.
+func OptionalAuth(tokens *auth.TokenIssuer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			userID, problem := validateBearer(tokens, authHeader)
+			if problem != "" {
+				http.Error(w, "Unauthorized: "+problem, http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, withUserID(r, userID))
+		})
+	}
+}
+
+// RequireActive returns a middleware, for routes behind Auth, that answers
+// 403 when active reports the signed-in user's account is deactivated or
+// gone. Access tokens outlive deactivation until they expire, so without it
+// a deactivated user could go on writing until then.
+func RequireActive(active func(ctx context.Context, userID string) (bool, error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, err := active(r.Context(), GetUserID(r.Context()))
+			if err != nil {
+				logging.FromContext(r.Context()).Error("failed to check account", slog.Any("error", err))
+				httpx.WriteError(w, http.StatusInternalServerError, "internal_error", "something went wrong")
+				return
+			}
+			if !ok {
+				httpx.WriteError(w, http.StatusForbidden, "account_inactive", "this account is deactivated")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// validateBearer checks a "Bearer <jwt>" header. On failure it returns a
+// description of the problem instead of a userID.
+func validateBearer(tokens *auth.TokenIssuer, authHeader string) (userID, problem string) {
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", "invalid authorization header format"
+	}
+
+	userID, err := tokens.ValidateAccessToken(parts[1])
+	if err != nil {
+		return "", "invalid or expired token"
+	}
+	return userID, ""
+}
+
+// withUserID attaches userID to the request context and to the
+// request-scoped logger.
+func withUserID(r *http.Request, userID string) *http.Request {
+	ctx := context.WithValue(r.Context(), UserIDKey, userID)
+	logging.AddAttrs(ctx, slog.String("user_id", userID))
+	return r.WithContext(ctx)
 }
 
 // GetUserID returns the userID from the context if it exists.
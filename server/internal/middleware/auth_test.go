@@ -1,10 +1,12 @@
 package middleware
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -43,14 +45,14 @@ func TestAuth(t *testing.T) {
 	priv, pub, err := generateTestKeys()
 	require.NoError(t, err)
 
-	err = auth.InitJWT(priv, pub)
+	tokens, err := auth.NewTokenIssuer(priv, pub, auth.SystemClock)
 	require.NoError(t, err)
 
 	userID := "user-123"
-	token, err := auth.GenerateAccessToken(userID)
+	token, err := tokens.GenerateAccessToken(userID)
 	require.NoError(t, err)
 
-	handler := Auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := Auth(tokens)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		gotUserID := GetUserID(r.Context())
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, "OK: %s", gotUserID)
@@ -99,3 +101,83 @@ func TestAuth(t *testing.T) {
 		assert.Contains(t, w.Body.String(), "invalid or expired token")
 	})
 }
+
+func TestOptionalAuth(t *testing.T) {
+	priv, pub, err := generateTestKeys()
+	require.NoError(t, err)
+
+	tokens, err := auth.NewTokenIssuer(priv, pub, auth.SystemClock)
+	require.NoError(t, err)
+
+	token, err := tokens.GenerateAccessToken("user-123")
+	require.NoError(t, err)
+
+	handler := OptionalAuth(tokens)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "OK: %s", GetUserID(r.Context()))
+	}))
+
+	t.Run("ValidToken", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "OK: user-123", w.Body.String())
+	})
+
+	t.Run("MissingHeaderIsAnonymous", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "OK: ", w.Body.String())
+	})
+
+	t.Run("InvalidTokenIsRejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer invalid-token")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid or expired token")
+	})
+}
+
+func TestRequireActive(t *testing.T) {
+	active := map[string]bool{"alice": true, "bob": false}
+	handler := RequireActive(func(_ context.Context, userID string) (bool, error) {
+		if userID == "broken" {
+			return false, errors.New("database down")
+		}
+		return active[userID], nil
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	do := func(userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, withUserID(req, userID))
+		return w
+	}
+
+	t.Run("ActiveAccount", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, do("alice").Code)
+	})
+
+	t.Run("DeactivatedAccount", func(t *testing.T) {
+		w := do("bob")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "account_inactive")
+		assert.Equal(t, http.StatusForbidden, do("carol").Code, "accounts that are gone too")
+	})
+
+	t.Run("CheckFails", func(t *testing.T) {
+		assert.Equal(t, http.StatusInternalServerError, do("broken").Code)
+	})
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompress(t *testing.T) {
+	largeBody := strings.Repeat(`{"id":"post","content":"hello world"},`, 100)
+
+	jsonHandler := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		})
+	}
+
+	decode := func(t *testing.T, encoding string, body io.Reader) string {
+		t.Helper()
+		var r io.Reader
+		switch encoding {
+		case "gzip":
+			gr, err := gzip.NewReader(body)
+			require.NoError(t, err)
+			r = gr
+		case "zstd":
+			zr, err := zstd.NewReader(body)
+			require.NoError(t, err)
+			defer zr.Close()
+			r = zr
+		case "br":
+			r = brotli.NewReader(body)
+		default:
+			r = body
+		}
+		out, err := io.ReadAll(r)
+		require.NoError(t, err)
+		return string(out)
+	}
+
+	t.Run("Negotiates each supported encoding", func(t *testing.T) {
+		handler := Compress(DefaultCompressMinSize)(jsonHandler(largeBody))
+
+		for _, encoding := range []string{"gzip", "zstd", "br"} {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", encoding)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, encoding, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			assert.Equal(t, largeBody, decode(t, encoding, w.Body))
+		}
+	})
+
+	t.Run("Honours q-values and server preference", func(t *testing.T) {
+		assert.Equal(t, "gzip", negotiateEncoding("gzip;q=1.0, br;q=0.5"))
+		assert.Equal(t, "zstd", negotiateEncoding("gzip, br, zstd"))
+		assert.Equal(t, "br", negotiateEncoding("br, gzip"))
+		assert.Equal(t, "zstd", negotiateEncoding("*"))
+		assert.Equal(t, "gzip", negotiateEncoding("*;q=0.1, gzip"))
+		assert.Equal(t, "", negotiateEncoding("identity"))
+		assert.Equal(t, "", negotiateEncoding("gzip;q=0"))
+		assert.Equal(t, "", negotiateEncoding(""))
+	})
+
+	t.Run("Small responses are not compressed", func(t *testing.T) {
+		handler := Compress(DefaultCompressMinSize)(jsonHandler(`{"ok":true}`))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, `{"ok":true}`, w.Body.String())
+	})
+
+	t.Run("Incompressible content types are left alone", func(t *testing.T) {
+		handler := Compress(DefaultCompressMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(largeBody))
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, largeBody, w.Body.String())
+	})
+
+	t.Run("Status code is preserved", func(t *testing.T) {
+		handler := Compress(DefaultCompressMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(largeBody))
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, largeBody, decode(t, "gzip", w.Body))
+	})
+
+	t.Run("No content responses pass through", func(t *testing.T) {
+		handler := Compress(DefaultCompressMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		req := httptest.NewRequest(http.MethodDelete, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("Flush streams small chunks through the Logger", func(t *testing.T) {
+		handler := Logger(Compress(DefaultCompressMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("chunk-1"))
+			require.NoError(t, http.NewResponseController(w).Flush())
+			w.Write([]byte("chunk-2"))
+		})))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.True(t, w.Flushed)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "chunk-1chunk-2", decode(t, "gzip", w.Body))
+	})
+}
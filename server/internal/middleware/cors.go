@@ -2,51 +2,242 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
-// CORS returns a middleware that adds Cross-Origin Resource Sharing (CORS) headers.
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"}
+	defaultCORSHeaders = []string{"Accept", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "X-Request-ID"}
+	defaultCORSExposed = []string{RequestIDHeader, "ETag"}
+)
+
+// CORSPolicy describes which cross-origin requests are allowed.
+type CORSPolicy struct {
+	// AllowedOrigins holds exact origins ("https://app.example.com"),
+	// single-wildcard patterns ("https://*.example.com") or "*" for any origin.
+	AllowedOrigins []string
+	// AllowedMethods defaults to GET, POST, PUT, DELETE, PATCH and OPTIONS.
+	AllowedMethods []string
+	// AllowedHeaders defaults to the headers our clients send, including
+	// Authorization and X-Request-ID.
+	AllowedHeaders []string
+	// ExposedHeaders defaults to X-Request-ID and ETag.
+	ExposedHeaders []string
+	// AllowCredentials permits cookies. It is never honoured together with a
+	// "*" origin, which is answered with a literal "*" instead of a reflection.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight. Defaults to 5 minutes.
+	MaxAge time.Duration
+}
+
+// CORS returns a middleware for a credentialed policy over a comma-separated
+// list of allowed origins, using the default methods and headers.
 func CORS(allowedOrigins string) func(http.Handler) http.Handler {
-	origins := strings.Split(allowedOrigins, ",")
-	for i := range origins {
-		origins[i] = strings.TrimSpace(origins[i])
+	return NewCORS(CORSPolicy{
+		AllowedOrigins:   splitList(allowedOrigins),
+		AllowCredentials: true,
+	})
+}
+
+// NewCORS returns a middleware that adds Cross-Origin Resource Sharing (CORS)
+// headers according to policy and answers preflight requests, rejecting those
+// that ask for a method or header the policy does not allow.
+func NewCORS(policy CORSPolicy) func(http.Handler) http.Handler {
+	return NewReloadableCORS(policy).Handler
+}
+
+// ReloadableCORS is a CORS middleware whose policy can be replaced while the
+// server is running, e.g. when the configuration is reloaded.
+type ReloadableCORS struct {
+	rules atomic.Pointer[corsRules]
+}
+
+// NewReloadableCORS returns a ReloadableCORS enforcing policy.
+func NewReloadableCORS(policy CORSPolicy) *ReloadableCORS {
+	c := &ReloadableCORS{}
+	c.Update(policy)
+	return c
+}
+
+// Update replaces the policy. Requests already in flight keep the old one.
+func (c *ReloadableCORS) Update(policy CORSPolicy) {
+	c.rules.Store(compileCORS(policy))
+}
+
+// corsRules is a CORSPolicy with defaults applied and lookups precomputed.
+type corsRules struct {
+	matcher        originMatcher
+	credentials    bool
+	allowedMethods map[string]bool
+	allowedHeaders map[string]bool
+	methodsValue   string
+	headersValue   string
+	exposedValue   string
+	maxAgeValue    string
+}
+
+func compileCORS(policy CORSPolicy) *corsRules {
+	matcher := newOriginMatcher(policy.AllowedOrigins)
+
+	methods := policy.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := policy.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	exposed := policy.ExposedHeaders
+	if len(exposed) == 0 {
+		exposed = defaultCORSExposed
+	}
+	maxAge := policy.MaxAge
+	if maxAge == 0 {
+		maxAge = 5 * time.Minute
 	}
 
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			origin := r.Header.Get("Origin")
-			if origin == "" {
-				next.ServeHTTP(w, r)
+	rules := &corsRules{
+		matcher:        matcher,
+		credentials:    policy.AllowCredentials && !matcher.any,
+		allowedMethods: make(map[string]bool, len(methods)),
+		allowedHeaders: make(map[string]bool, len(headers)),
+		methodsValue:   strings.Join(methods, ", "),
+		headersValue:   strings.Join(headers, ", "),
+		exposedValue:   strings.Join(exposed, ", "),
+		maxAgeValue:    strconv.Itoa(int(maxAge / time.Second)),
+	}
+	for _, m := range methods {
+		rules.allowedMethods[strings.ToUpper(m)] = true
+	}
+	for _, h := range headers {
+		rules.allowedHeaders[http.CanonicalHeaderKey(h)] = true
+	}
+	return rules
+}
+
+// Handler is the middleware.
+func (c *ReloadableCORS) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rules := c.rules.Load()
+
+		h := w.Header()
+		h.Add("Vary", "Origin")
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if !rules.matcher.matches(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
+			next.ServeHTTP(w, r)
+			return
+		}
 
-			allow := false
-			if allowedOrigins == "*" {
-				allow = true
-			} else {
-				for _, o := range origins {
-					if o == origin {
-						allow = true
-						break
-					}
+		if preflight {
+			if !rules.allowedMethods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			for _, requested := range splitList(strings.Join(r.Header.Values("Access-Control-Request-Headers"), ",")) {
+				if !rules.allowedHeaders[http.CanonicalHeaderKey(requested)] {
+					w.WriteHeader(http.StatusForbidden)
+					return
 				}
 			}
+		}
 
-			if allow {
-				w.Header().Set("Access-Control-Allow-Origin", origin)
-				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Request-ID")
-				w.Header().Set("Access-Control-Allow-Credentials", "true")
-				w.Header().Set("Access-Control-Max-Age", "300")
-			}
+		if rules.matcher.any {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if rules.credentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
 
-			// Handle preflight requests
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusNoContent)
-				return
-			}
+		if preflight {
+			h.Set("Access-Control-Allow-Methods", rules.methodsValue)
+			h.Set("Access-Control-Allow-Headers", rules.headersValue)
+			h.Set("Access-Control-Max-Age", rules.maxAgeValue)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
 
-			next.ServeHTTP(w, r)
-		})
+		h.Set("Access-Control-Expose-Headers", rules.exposedValue)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// originMatcher matches request origins against exact and wildcard patterns.
+type originMatcher struct {
+	any      bool
+	exact    map[string]bool
+	wildcard [][2]string // prefix and suffix around the "*"
+}
+
+func newOriginMatcher(origins []string) originMatcher {
+	m := originMatcher{exact: make(map[string]bool)}
+	for _, o := range origins {
+		o = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(o), "/"))
+		switch {
+		case o == "":
+		case o == "*":
+			m.any = true
+		case strings.Contains(o, "*"):
+			prefix, suffix, _ := strings.Cut(o, "*")
+			m.wildcard = append(m.wildcard, [2]string{prefix, suffix})
+		default:
+			m.exact[o] = true
+		}
+	}
+	return m
+}
+
+func (m originMatcher) matches(origin string) bool {
+	if m.any {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if m.exact[origin] {
+		return true
+	}
+
+	for _, w := range m.wildcard {
+		prefix, suffix := w[0], w[1]
+		if len(origin) <= len(prefix)+len(suffix) || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+			continue
+		}
+		// The wildcard stands for one or more subdomain labels, nothing else.
+		label := origin[len(prefix) : len(origin)-len(suffix)]
+		if !strings.ContainsAny(label, "/:@?#") && !strings.HasPrefix(label, ".") && !strings.HasSuffix(label, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList splits a comma-separated list, trimming blanks and empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
 	}
+	return out
 }
@@ -38,7 +38,8 @@ func TestCORS(t *testing.T) {
 		wildcardHandler.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, "http://another-site.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
 		assert.Equal(t, "ok", w.Body.String())
 	})
 
@@ -57,6 +58,8 @@ func TestCORS(t *testing.T) {
 	t.Run("Preflight request", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodOptions, "/test", nil)
 		req.Header.Set("Origin", "http://localhost:3000")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		req.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
 		w := httptest.NewRecorder()
 
 		handler.ServeHTTP(w, req)
@@ -64,9 +67,68 @@ func TestCORS(t *testing.T) {
 		assert.Equal(t, http.StatusNoContent, w.Code)
 		assert.Equal(t, "http://localhost:3000", w.Header().Get("Access-Control-Allow-Origin"))
 		assert.Equal(t, "GET, POST, PUT, DELETE, PATCH, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "300", w.Header().Get("Access-Control-Max-Age"))
 		assert.Equal(t, "", w.Body.String())
 	})
 
+	t.Run("Preflight with disallowed method", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/test", nil)
+		req.Header.Set("Origin", "http://localhost:3000")
+		req.Header.Set("Access-Control-Request-Method", "TRACE")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Preflight with disallowed header", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/test", nil)
+		req.Header.Set("Origin", "http://localhost:3000")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		req.Header.Set("Access-Control-Request-Headers", "X-Secret")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Preflight from disallowed origin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/test", nil)
+		req.Header.Set("Origin", "http://disallowed.com")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Plain OPTIONS request is not a preflight", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/test", nil)
+		req.Header.Set("Origin", "http://localhost:3000")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "ok", w.Body.String())
+	})
+
+	t.Run("Vary and exposed headers", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Origin", "http://localhost:3000")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, "Origin", w.Header().Get("Vary"))
+		assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "X-Request-ID")
+	})
+
 	t.Run("Empty origin", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/test", nil)
 		w := httptest.NewRecorder()
@@ -78,3 +140,92 @@ func TestCORS(t *testing.T) {
 		assert.Equal(t, "ok", w.Body.String())
 	})
 }
+
+func TestNewCORS(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	t.Run("Wildcard subdomain pattern", func(t *testing.T) {
+		handler := NewCORS(CORSPolicy{
+			AllowedOrigins:   []string{"https://*.example.com"},
+			AllowCredentials: true,
+		})(ok)
+
+		cases := map[string]bool{
+			"https://app.example.com":         true,
+			"https://a.b.example.com":         true,
+			"https://APP.example.com":         true,
+			"https://example.com":             false,
+			"http://app.example.com":          false,
+			"https://app.example.com:8443":    false,
+			"https://evilexample.com":         false,
+			"https://app.example.com.evil.io": false,
+		}
+		for origin, allowed := range cases {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Origin", origin)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if allowed {
+				assert.Equal(t, origin, w.Header().Get("Access-Control-Allow-Origin"), origin)
+				assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"), origin)
+			} else {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), origin)
+			}
+		}
+	})
+
+	t.Run("Public policy never allows credentials", func(t *testing.T) {
+		handler := NewCORS(CORSPolicy{
+			AllowedOrigins:   []string{"*"},
+			AllowedMethods:   []string{"GET"},
+			AllowCredentials: true,
+		})(ok)
+
+		req := httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("Origin", "https://anywhere.io")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "GET", w.Header().Get("Access-Control-Allow-Methods"))
+
+		req = httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("Origin", "https://anywhere.io")
+		req.Header.Set("Access-Control-Request-Method", "DELETE")
+		w = httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestReloadableCORS(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	cors := NewReloadableCORS(CORSPolicy{AllowedOrigins: []string{"https://old.example.com"}, AllowCredentials: true})
+	handler := cors.Handler(ok)
+
+	allowed := func(origin string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	assert.Equal(t, "https://old.example.com", allowed("https://old.example.com"))
+	assert.Empty(t, allowed("https://new.example.com"))
+
+	cors.Update(CORSPolicy{AllowedOrigins: []string{"https://new.example.com"}, AllowCredentials: true})
+
+	assert.Empty(t, allowed("https://old.example.com"))
+	assert.Equal(t, "https://new.example.com", allowed("https://new.example.com"))
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETag is a middleware that adds a strong ETag to successful GET responses and
+// answers conditional requests (If-None-Match / If-Modified-Since) with
+// 304 Not Modified.
+//
+// The ETag is the hash of the bytes actually sent, so it must sit outside
+// Compress: each content encoding then gets its own validator. Handlers may set
+// their own ETag or Last-Modified headers, which are used as-is. Responses that
+// are flushed early are streamed through untouched.
+func ETag(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ew := &etagWriter{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+		ew.finish(r)
+	})
+}
+
+// etagWriter buffers a response so its validator can be computed before the
+// header is sent.
+type etagWriter struct {
+	http.ResponseWriter
+	status    int
+	buf       []byte
+	streaming bool
+}
+
+func (ew *etagWriter) WriteHeader(code int) {
+	if ew.streaming {
+		ew.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if ew.status != 0 {
+		return
+	}
+	if code < http.StatusOK {
+		ew.ResponseWriter.WriteHeader(code)
+		return
+	}
+	ew.status = code
+}
+
+func (ew *etagWriter) Write(b []byte) (int, error) {
+	if ew.streaming {
+		return ew.ResponseWriter.Write(b)
+	}
+	if ew.status == 0 {
+		ew.status = http.StatusOK
+	}
+	ew.buf = append(ew.buf, b...)
+	return len(b), nil
+}
+
+// Flush gives up on validating the response and switches to pass-through.
+func (ew *etagWriter) Flush() {
+	if !ew.streaming {
+		ew.streaming = true
+		if ew.status == 0 {
+			ew.status = http.StatusOK
+		}
+		ew.ResponseWriter.WriteHeader(ew.status)
+		if len(ew.buf) > 0 {
+			ew.ResponseWriter.Write(ew.buf)
+			ew.buf = nil
+		}
+	}
+	http.NewResponseController(ew.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (ew *etagWriter) Unwrap() http.ResponseWriter {
+	return ew.ResponseWriter
+}
+
+func (ew *etagWriter) finish(r *http.Request) {
+	if ew.streaming || ew.status == 0 {
+		return
+	}
+
+	h := ew.Header()
+	if ew.status == http.StatusOK {
+		if h.Get("ETag") == "" {
+			h.Set("ETag", strongETag(ew.buf))
+		}
+
+		if notModified(r, h) {
+			h.Del("Content-Type")
+			h.Del("Content-Length")
+			h.Del("Content-Encoding")
+			if h.Get("ETag") != "" {
+				h.Del("Last-Modified")
+			}
+			ew.ResponseWriter.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	ew.ResponseWriter.WriteHeader(ew.status)
+	if len(ew.buf) > 0 {
+		ew.ResponseWriter.Write(ew.buf)
+	}
+}
+
+// strongETag derives a quoted strong entity tag from the response body.
+func strongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified evaluates the request's cache validators against the response
+// headers, following the precedence rules of RFC 9110 section 13.2.2.
+func notModified(r *http.Request, h http.Header) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagListMatches(inm, h.Get("ETag"))
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	lm := h.Get("Last-Modified")
+	if ims == "" || lm == "" {
+		return false
+	}
+
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lm)
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// etagListMatches performs the weak comparison required for If-None-Match.
+func etagListMatches(list, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETag(t *testing.T) {
+	handler := ETag(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"username":"alice"}`))
+	}))
+
+	t.Run("Adds a strong ETag", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		etag := w.Header().Get("ETag")
+		assert.NotEmpty(t, etag)
+		assert.NotContains(t, etag, "W/")
+		assert.Equal(t, `{"username":"alice"}`, w.Body.String())
+	})
+
+	t.Run("Matching If-None-Match returns 304", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		etag := w.Header().Get("ETag")
+
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", `"other", `+etag)
+		w = httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+		assert.Empty(t, w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("Weak validator matches strong ETag", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		etag := w.Header().Get("ETag")
+
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", "W/"+etag)
+		w = httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("Stale If-None-Match returns full body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", `"stale"`)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"username":"alice"}`, w.Body.String())
+	})
+
+	t.Run("If-Modified-Since uses handler Last-Modified", func(t *testing.T) {
+		modified := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		lmHandler := ETag(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+			w.Write([]byte("profile"))
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-Modified-Since", modified.Add(time.Minute).Format(http.TimeFormat))
+		w := httptest.NewRecorder()
+		lmHandler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-Modified-Since", modified.Add(-time.Minute).Format(http.TimeFormat))
+		w = httptest.NewRecorder()
+		lmHandler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "profile", w.Body.String())
+	})
+
+	t.Run("Non-GET and error responses are untouched", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Empty(t, w.Header().Get("ETag"))
+
+		notFound := ETag(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "not found", http.StatusNotFound)
+		}))
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		w = httptest.NewRecorder()
+		notFound.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, w.Header().Get("ETag"))
+	})
+
+	t.Run("Compressed representations get distinct ETags", func(t *testing.T) {
+		large := make([]byte, 4096)
+		for i := range large {
+			large[i] = 'a'
+		}
+		chain := ETag(Compress(DefaultCompressMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write(large)
+		})))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		chain.ServeHTTP(w, req)
+		plain := w.Header().Get("ETag")
+
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w = httptest.NewRecorder()
+		chain.ServeHTTP(w, req)
+		gzipped := w.Header().Get("ETag")
+
+		require.NotEmpty(t, plain)
+		require.NotEmpty(t, gzipped)
+		assert.NotEqual(t, plain, gzipped)
+
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		req.Header.Set("If-None-Match", gzipped)
+		w = httptest.NewRecorder()
+		chain.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("Flushed responses stream without an ETag", func(t *testing.T) {
+		streaming := ETag(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("event-1"))
+			w.(http.Flusher).Flush()
+			w.Write([]byte("event-2"))
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		streaming.ServeHTTP(w, req)
+
+		assert.True(t, w.Flushed)
+		assert.Empty(t, w.Header().Get("ETag"))
+		assert.Equal(t, "event-1event-2", w.Body.String())
+	})
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/httpx"
+)
+
+// Timeout returns a middleware that cancels the request context after d.
+//
+// Handlers are expected to honour ctx.Done(). If the deadline passes before the
+// handler has written anything, a structured 503 is sent once it returns. A
+// non-positive d disables the middleware.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			wrapped := wrapResponseWriter(w)
+			next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && !wrapped.wroteHeader {
+				httpx.WriteError(w, http.StatusServiceUnavailable, "request_timeout",
+					"request did not complete within "+d.String())
+			}
+		})
+	}
+}
+
+// MaxBytes returns a middleware that caps the request body at n bytes.
+//
+// Requests that declare a larger Content-Length are rejected up front with a
+// structured 413. Otherwise the body is wrapped in http.MaxBytesReader, so
+// handlers see an *http.MaxBytesError once they read past the limit (see
+// httpx.DecodeJSON). A non-positive n disables the middleware.
+func MaxBytes(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				httpx.WriteTooLarge(w, n)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
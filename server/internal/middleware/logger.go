@@ -2,8 +2,11 @@ package middleware
 
 import (
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"time"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/logging"
 )
 
 // responseWriter is a minimal wrapper for http.ResponseWriter that allows us to
@@ -39,27 +42,73 @@ func (rw *responseWriter) Write(buf []byte) (int, error) {
 	return rw.ResponseWriter.Write(buf)
 }
 
+// Flush forwards to the underlying writer so streaming handlers still work
+// behind the Logger.
+func (rw *responseWriter) Flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// LoggerConfig configures RequestLogger.
+type LoggerConfig struct {
+	// SuccessSampleRate is the fraction (0-1) of 2xx/3xx responses that get
+	// an access log line. Client and server errors are always logged.
+	SuccessSampleRate float64
+}
+
 // Logger is a middleware that logs the start and end of each request, along
-// with some useful data about what happened.
+// with some useful data about what happened. Every request is logged.
 func Logger(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		wrapped := wrapResponseWriter(w)
-
-		next.ServeHTTP(wrapped, r)
-
-		status := wrapped.Status()
-		if status == 0 {
-			status = http.StatusOK
-		}
-
-		slog.Info("request handled",
-			slog.String("method", r.Method),
-			slog.String("path", r.URL.Path),
-			slog.Int("status", status),
-			slog.Duration("latency", time.Since(start)),
-			slog.String("request_id", GetRequestID(r.Context())),
-		)
-	})
+	return RequestLogger(LoggerConfig{SuccessSampleRate: 1})(next)
+}
+
+// RequestLogger returns a middleware that attaches a request-scoped logger
+// (see logging.FromContext) carrying the request ID and client IP, and writes
+// an access log line once the request completes. It must run after RequestID
+// and RealIP.
+func RequestLogger(cfg LoggerConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			ctx := logging.WithLogger(r.Context(), slog.Default().With(
+				slog.String("request_id", GetRequestID(r.Context())),
+				slog.String("client_ip", GetClientIP(r.Context())),
+			))
+			r = r.WithContext(ctx)
+
+			wrapped := wrapResponseWriter(w)
+
+			next.ServeHTTP(wrapped, r)
+
+			status := wrapped.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			level := slog.LevelInfo
+			switch {
+			case status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			case cfg.SuccessSampleRate < 1 && rand.Float64() >= cfg.SuccessSampleRate:
+				return
+			}
+
+			logging.FromContext(ctx).With(logging.ModuleKey, "http").LogAttrs(ctx, level, "request handled",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Duration("latency", time.Since(start)),
+			)
+		})
+	}
 }
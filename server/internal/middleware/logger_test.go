@@ -1,12 +1,18 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/hrutav-modha/social-media-app/server/internal/logging"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLogger(t *testing.T) {
@@ -42,3 +48,51 @@ func TestLogger(t *testing.T) {
 		assert.Equal(t, "ok", w.Body.String())
 	})
 }
+
+func TestRequestLogger(t *testing.T) {
+	var logs bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	t.Run("Carries request ID, user ID and route", func(t *testing.T) {
+		logs.Reset()
+		r := chi.NewRouter()
+		r.Use(RequestID)
+		r.Use(Logger)
+		r.Get("/users/{username}", func(w http.ResponseWriter, r *http.Request) {
+			logging.AddAttrs(r.Context(), slog.String("user_id", "user-1"))
+			logging.FromContext(r.Context()).Info("handler log")
+			w.Write([]byte("ok"))
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/users/alice", nil)
+		req.Header.Set(RequestIDHeader, "req-42")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+		require.Len(t, lines, 2)
+		for _, line := range lines {
+			assert.Contains(t, line, `"request_id":"req-42"`)
+			assert.Contains(t, line, `"user_id":"user-1"`)
+			assert.Contains(t, line, `"route":"/users/{username}"`)
+		}
+		assert.Contains(t, lines[1], `"msg":"request handled"`)
+	})
+
+	t.Run("Samples successful requests but keeps errors", func(t *testing.T) {
+		logs.Reset()
+		status := http.StatusOK
+		handler := RequestLogger(LoggerConfig{SuccessSampleRate: 0})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Empty(t, logs.String())
+
+		status = http.StatusInternalServerError
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Contains(t, logs.String(), `"level":"ERROR"`)
+		assert.Contains(t, logs.String(), `"status":500`)
+	})
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const (
+	// ClientIPKey is the key used to store and retrieve the client IP from the context.
+	ClientIPKey contextKey = "clientIP"
+)
+
+// RealIP returns a middleware that resolves the originating client IP and
+// attaches it to the request context (see GetClientIP).
+//
+// Forwarding headers are only honoured when the direct peer is one of the
+// trusted proxies. The Forwarded header (RFC 7239) takes precedence over
+// X-Forwarded-For. The chain is walked from the right, skipping trusted hops,
+// so a client cannot spoof its address by prepending entries. r.RemoteAddr is
+// left untouched.
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	isTrusted := func(addr netip.Addr) bool {
+		for _, p := range trusted {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clientIP := remoteIP(r.RemoteAddr)
+
+			if clientIP.IsValid() && isTrusted(clientIP) {
+				hops := forwardedFor(r.Header.Values("Forwarded"))
+				if len(hops) == 0 {
+					hops = xForwardedFor(r.Header.Values("X-Forwarded-For"))
+				}
+
+				for i := len(hops) - 1; i >= 0; i-- {
+					hop, err := parseHop(hops[i])
+					if err != nil {
+						// Obfuscated or garbage entry: stop at the last hop we trust.
+						break
+					}
+					clientIP = hop
+					if !isTrusted(hop) {
+						break
+					}
+				}
+			}
+
+			value := r.RemoteAddr
+			if clientIP.IsValid() {
+				value = clientIP.String()
+			}
+			ctx := context.WithValue(r.Context(), ClientIPKey, value)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetClientIP returns the client IP from the context if it exists.
+func GetClientIP(ctx context.Context) string {
+	if ip, ok := ctx.Value(ClientIPKey).(string); ok {
+		return ip
+	}
+	return ""
+}
+
+func remoteIP(remoteAddr string) netip.Addr {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+// xForwardedFor flattens every X-Forwarded-For header into a single hop list.
+func xForwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, hop := range strings.Split(v, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+// forwardedFor extracts the for= parameter of every element of every
+// Forwarded header, e.g. `for=192.0.2.60;proto=http, for="[2001:db8::1]:80"`.
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, element := range strings.Split(v, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hops = append(hops, strings.Trim(value, `"`))
+				}
+			}
+		}
+	}
+	return hops
+}
+
+// parseHop parses a single forwarding entry, which may carry a port and, for
+// IPv6, square brackets.
+func parseHop(hop string) (netip.Addr, error) {
+	if addrPort, err := netip.ParseAddrPort(hop); err == nil {
+		return addrPort.Addr().Unmap(), nil
+	}
+	addr, err := netip.ParseAddr(strings.Trim(hop, "[]"))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return addr.Unmap(), nil
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	var got string
+	handler := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = GetClientIP(r.Context())
+	}))
+
+	serve := func(remoteAddr string, headers map[string]string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return got
+	}
+
+	t.Run("Untrusted peer headers are ignored", func(t *testing.T) {
+		ip := serve("203.0.113.7:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"})
+		assert.Equal(t, "203.0.113.7", ip)
+	})
+
+	t.Run("Trusted peer uses X-Forwarded-For", func(t *testing.T) {
+		ip := serve("10.0.0.2:1234", map[string]string{"X-Forwarded-For": "198.51.100.9"})
+		assert.Equal(t, "198.51.100.9", ip)
+	})
+
+	t.Run("Spoofed leftmost entries are skipped", func(t *testing.T) {
+		ip := serve("10.0.0.2:1234", map[string]string{"X-Forwarded-For": "6.6.6.6, 198.51.100.9, 10.0.0.3"})
+		assert.Equal(t, "198.51.100.9", ip)
+	})
+
+	t.Run("Forwarded header takes precedence", func(t *testing.T) {
+		ip := serve("10.0.0.2:1234", map[string]string{
+			"Forwarded":       `for="[2001:db8::17]:4711";proto=https, for=10.0.0.3`,
+			"X-Forwarded-For": "198.51.100.9",
+		})
+		assert.Equal(t, "2001:db8::17", ip)
+	})
+
+	t.Run("Obfuscated hop stops the walk", func(t *testing.T) {
+		ip := serve("10.0.0.2:1234", map[string]string{"Forwarded": "for=_hidden, for=10.0.0.3"})
+		assert.Equal(t, "10.0.0.3", ip)
+	})
+
+	t.Run("No forwarding headers", func(t *testing.T) {
+		ip := serve("10.0.0.2:1234", nil)
+		assert.Equal(t, "10.0.0.2", ip)
+	})
+}
+
+func TestLoggerIncludesClientIP(t *testing.T) {
+	var logs bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	handler := RealIP(trusted)(Logger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.1.2.3:5555"
+	req.Header.Set("X-Forwarded-For", "198.51.100.9")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Contains(t, logs.String(), `"client_ip":"198.51.100.9"`)
+}
@@ -4,6 +4,8 @@ import (
 	"log/slog"
 	"net/http"
 	"runtime/debug"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/logging"
 )
 
 // Recoverer is a middleware that recovers from panics, logs the panic (including a stack trace),
@@ -20,10 +22,9 @@ func Recoverer(next http.Handler) http.Handler {
 
 				stack := string(debug.Stack())
 
-				slog.Error("panic recovered",
+				logging.FromContext(r.Context()).Error("panic recovered",
 					slog.Any("error", err),
 					slog.String("stack", stack),
-					slog.String("request_id", GetRequestID(r.Context())),
 					slog.String("method", r.Method),
 					slog.String("path", r.URL.Path),
 				)
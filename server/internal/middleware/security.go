@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/logging"
+)
+
+const (
+	// CSPNonceKey is the key used to store and retrieve the CSP nonce from the context.
+	CSPNonceKey contextKey = "cspNonce"
+
+	// CSPNoncePlaceholder is replaced in the configured policy with a fresh
+	// 'nonce-…' source for every request.
+	CSPNoncePlaceholder = "{nonce}"
+
+	// CSPReportPath is where browsers send violation reports.
+	CSPReportPath = "/csp-report"
+
+	// maxCSPReportBytes caps the size of a single violation report.
+	maxCSPReportBytes = 64 << 10
+)
+
+// SecurityHeadersConfig configures the SecurityHeaders middleware.
+type SecurityHeadersConfig struct {
+	// HSTSMaxAge is the Strict-Transport-Security max-age. Zero disables HSTS.
+	HSTSMaxAge time.Duration
+	// ContentSecurityPolicy is the policy to send. It may contain
+	// CSPNoncePlaceholder. An empty policy disables the CSP header.
+	ContentSecurityPolicy string
+	// CSPReportOnly sends the policy as Content-Security-Policy-Report-Only
+	// so violations are reported to CSPReportPath but not blocked.
+	CSPReportOnly bool
+	// ReferrerPolicy defaults to "strict-origin-when-cross-origin".
+	ReferrerPolicy string
+}
+
+// SecurityHeaders returns a middleware that sets HSTS, X-Content-Type-Options,
+// Referrer-Policy, X-Frame-Options and Content-Security-Policy headers.
+func SecurityHeaders(cfg SecurityHeadersConfig) func(http.Handler) http.Handler {
+	referrerPolicy := cfg.ReferrerPolicy
+	if referrerPolicy == "" {
+		referrerPolicy = "strict-origin-when-cross-origin"
+	}
+
+	var hsts string
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(cfg.HSTSMaxAge/time.Second), 10) + "; includeSubDomains"
+	}
+
+	cspHeader := "Content-Security-Policy"
+	policy := strings.TrimSpace(cfg.ContentSecurityPolicy)
+	if cfg.CSPReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
+	if policy != "" && !strings.Contains(policy, "report-uri") {
+		policy = strings.TrimSuffix(policy, ";") + "; report-uri " + CSPReportPath
+	}
+	needsNonce := strings.Contains(policy, CSPNoncePlaceholder)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("Referrer-Policy", referrerPolicy)
+			h.Set("X-Frame-Options", "DENY")
+			if hsts != "" {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+
+			if policy != "" {
+				value := policy
+				if needsNonce {
+					nonce, err := generateNonce()
+					if err != nil {
+						logging.FromContext(r.Context()).Error("failed to generate CSP nonce", slog.Any("error", err))
+						http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+						return
+					}
+					value = strings.ReplaceAll(value, CSPNoncePlaceholder, "'nonce-"+nonce+"'")
+					r = r.WithContext(context.WithValue(r.Context(), CSPNonceKey, nonce))
+				}
+				h.Set(cspHeader, value)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GetCSPNonce returns the CSP nonce for the request if one was generated.
+func GetCSPNonce(ctx context.Context) string {
+	if nonce, ok := ctx.Value(CSPNonceKey).(string); ok {
+		return nonce
+	}
+	return ""
+}
+
+func generateNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// cspViolation is the subset of a violation report that we log. Its JSON tags
+// follow the legacy report-uri format; Reporting API bodies are mapped onto it.
+type cspViolation struct {
+	DocumentURI        string `json:"document-uri"`
+	BlockedURI         string `json:"blocked-uri"`
+	ViolatedDirective  string `json:"violated-directive"`
+	EffectiveDirective string `json:"effective-directive"`
+	SourceFile         string `json:"source-file"`
+	LineNumber         int    `json:"line-number"`
+	Disposition        string `json:"disposition"`
+}
+
+// reportingAPIReport is one entry of an application/reports+json batch.
+type reportingAPIReport struct {
+	Type string `json:"type"`
+	Body struct {
+		DocumentURL        string `json:"documentURL"`
+		BlockedURL         string `json:"blockedURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+		Disposition        string `json:"disposition"`
+	} `json:"body"`
+}
+
+// CSPReport collects Content Security Policy violation reports and logs them.
+// It accepts both the legacy application/csp-report format and the Reporting
+// API's application/reports+json batches.
+func CSPReport(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCSPReportBytes))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var violations []cspViolation
+	if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+		var reports []reportingAPIReport
+		if err := json.Unmarshal(body, &reports); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, rep := range reports {
+			if rep.Type != "csp-violation" {
+				continue
+			}
+			violations = append(violations, cspViolation{
+				DocumentURI:        rep.Body.DocumentURL,
+				BlockedURI:         rep.Body.BlockedURL,
+				EffectiveDirective: rep.Body.EffectiveDirective,
+				SourceFile:         rep.Body.SourceFile,
+				LineNumber:         rep.Body.LineNumber,
+				Disposition:        rep.Body.Disposition,
+			})
+		}
+	} else {
+		var legacy struct {
+			Report cspViolation `json:"csp-report"`
+		}
+		if err := json.Unmarshal(body, &legacy); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		violations = append(violations, legacy.Report)
+	}
+
+	for _, v := range violations {
+		directive := v.EffectiveDirective
+		if directive == "" {
+			directive = v.ViolatedDirective
+		}
+		logging.FromContext(r.Context()).Warn("csp violation",
+			slog.String("document_uri", v.DocumentURI),
+			slog.String("blocked_uri", v.BlockedURI),
+			slog.String("directive", directive),
+			slog.String("source_file", v.SourceFile),
+			slog.Int("line_number", v.LineNumber),
+			slog.String("disposition", v.Disposition),
+		)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(GetCSPNonce(r.Context())))
+	})
+
+	t.Run("Sets static headers", func(t *testing.T) {
+		handler := SecurityHeaders(SecurityHeadersConfig{
+			HSTSMaxAge:            24 * time.Hour,
+			ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+		})(okHandler)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, "max-age=86400; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "strict-origin-when-cross-origin", w.Header().Get("Referrer-Policy"))
+		assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+		assert.Equal(t, "default-src 'none'; frame-ancestors 'none'; report-uri /csp-report", w.Header().Get("Content-Security-Policy"))
+		assert.Empty(t, w.Header().Get("Content-Security-Policy-Report-Only"))
+	})
+
+	t.Run("HSTS disabled with zero max-age", func(t *testing.T) {
+		handler := SecurityHeaders(SecurityHeadersConfig{})(okHandler)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+		assert.Empty(t, w.Header().Get("Content-Security-Policy"))
+	})
+
+	t.Run("Nonce is fresh per request and exposed in context", func(t *testing.T) {
+		handler := SecurityHeaders(SecurityHeadersConfig{
+			ContentSecurityPolicy: "script-src {nonce}",
+		})(okHandler)
+
+		var nonces []string
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			nonce := w.Body.String()
+			assert.NotEmpty(t, nonce)
+			assert.Equal(t, "script-src 'nonce-"+nonce+"'; report-uri /csp-report", w.Header().Get("Content-Security-Policy"))
+			nonces = append(nonces, nonce)
+		}
+		assert.NotEqual(t, nonces[0], nonces[1])
+	})
+
+	t.Run("Report-only mode", func(t *testing.T) {
+		handler := SecurityHeaders(SecurityHeadersConfig{
+			ContentSecurityPolicy: "default-src 'self'",
+			CSPReportOnly:         true,
+		})(okHandler)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Content-Security-Policy"))
+		assert.Equal(t, "default-src 'self'; report-uri /csp-report", w.Header().Get("Content-Security-Policy-Report-Only"))
+	})
+}
+
+func TestCSPReport(t *testing.T) {
+	var logs bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	t.Run("Legacy report format", func(t *testing.T) {
+		logs.Reset()
+		body := `{"csp-report":{"document-uri":"https://app.example.com/","blocked-uri":"https://evil.example.com/x.js","violated-directive":"script-src"}}`
+		req := httptest.NewRequest(http.MethodPost, CSPReportPath, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/csp-report")
+		w := httptest.NewRecorder()
+
+		CSPReport(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Contains(t, logs.String(), "csp violation")
+		assert.Contains(t, logs.String(), "https://evil.example.com/x.js")
+		assert.Contains(t, logs.String(), `"directive":"script-src"`)
+	})
+
+	t.Run("Reporting API batch", func(t *testing.T) {
+		logs.Reset()
+		body := `[{"type":"csp-violation","body":{"documentURL":"https://app.example.com/","blockedURL":"inline","effectiveDirective":"style-src"}},{"type":"deprecation","body":{}}]`
+		req := httptest.NewRequest(http.MethodPost, CSPReportPath, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/reports+json")
+		w := httptest.NewRecorder()
+
+		CSPReport(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, 1, strings.Count(logs.String(), "csp violation"))
+		assert.Contains(t, logs.String(), `"directive":"style-src"`)
+	})
+
+	t.Run("Malformed report", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, CSPReportPath, strings.NewReader("not json"))
+		w := httptest.NewRecorder()
+
+		CSPReport(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,323 @@
+// Package migrate applies the SQL migrations in the migrations package to
+// PostgreSQL. It keeps its state in golang-migrate's schema_migrations table,
+// so databases migrated with the migrate CLI can be managed by either tool.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrDirty is returned when a previous migration failed half-way and left
+	// the database marked dirty. It has to be repaired by hand.
+	ErrDirty = errors.New("database is dirty")
+
+	// ErrTooNew is returned when the database has migrations this binary does
+	// not know about, i.e. it was migrated by a newer release.
+	ErrTooNew = errors.New("database schema is newer than this binary")
+)
+
+// lockID is the Postgres advisory lock key held while migrating, so replicas
+// starting together apply each migration once. It is paired with a hash of
+// the current schema so migrators working on different schemas don't wait
+// for each other.
+const lockID int32 = 735_628_109
+
+// Migration is one numbered schema change.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes a migration and whether it has been applied.
+type Status struct {
+	Migration
+	Applied bool
+}
+
+var filePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Load reads migrations from fsys. Every version needs both an up and a down
+// file, even if one of them is empty.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	files := make(map[uint]int)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := filePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid version: %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("version %d is used by both %q and %q", version, m.Name, match[2])
+		}
+
+		files[uint(version)]++
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for _, m := range migrations {
+		if files[m.Version] != 2 {
+			return nil, fmt.Errorf("migration %d_%s needs exactly one up and one down file", m.Version, m.Name)
+		}
+	}
+	return migrations, nil
+}
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// New returns a Migrator for the migrations in fsys.
+func New(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// Latest returns the highest migration version known to the binary.
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+const createTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL PRIMARY KEY,
+    dirty BOOLEAN NOT NULL
+)`
+
+// Version returns the version the database is at, 0 if no migration has been
+// applied, and whether the last migration failed half-way.
+func (m *Migrator) Version(ctx context.Context) (uint, bool, error) {
+	var exists bool
+	err := m.pool.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if !exists {
+		return 0, false, nil
+	}
+
+	var v int64
+	var dirty bool
+	err = m.pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&v, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return uint(v), dirty, nil
+}
+
+// Check returns the number of migrations that have not been applied yet. It
+// fails with ErrDirty or ErrTooNew when the binary must not run against the
+// database as it is.
+func (m *Migrator) Check(ctx context.Context) (int, error) {
+	current, err := m.usableVersion(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, mig := range m.migrations {
+		if mig.Version > current {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// usableVersion returns the current version unless it is dirty or newer than
+// the binary's latest migration.
+func (m *Migrator) usableVersion(ctx context.Context) (uint, error) {
+	current, dirty, err := m.Version(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w at version %d", ErrDirty, current)
+	}
+	if current > m.Latest() {
+		return 0, fmt.Errorf("%w: database is at version %d, binary knows up to %d", ErrTooNew, current, m.Latest())
+	}
+	return current, nil
+}
+
+// withLock runs fn while holding the migration advisory lock, waiting for
+// other migrators to finish first.
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1, hashtext(current_schema()))`, lockID); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer func() {
+		// Use a fresh context so the lock is released even if ctx was
+		// cancelled. If that fails too, closing the session releases it.
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.Exec(unlockCtx, `SELECT pg_advisory_unlock($1, hashtext(current_schema()))`, lockID); err != nil {
+			conn.Conn().Close(unlockCtx)
+		}
+	}()
+
+	return fn()
+}
+
+// Up applies every pending migration and returns how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func() error {
+		var err error
+		applied, err = m.up(ctx)
+		return err
+	})
+	return applied, err
+}
+
+func (m *Migrator) up(ctx context.Context) (int, error) {
+	if _, err := m.pool.Exec(ctx, createTable); err != nil {
+		return 0, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	current, err := m.usableVersion(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, mig := range m.migrations {
+		if mig.Version <= current {
+			continue
+		}
+		if err := m.apply(ctx, mig.Up, int64(mig.Version)); err != nil {
+			return applied, fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// Down reverts the last steps migrations and returns how many were reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+	err := m.withLock(ctx, func() error {
+		var err error
+		reverted, err = m.down(ctx, steps)
+		return err
+	})
+	return reverted, err
+}
+
+func (m *Migrator) down(ctx context.Context, steps int) (int, error) {
+	current, err := m.usableVersion(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	reverted := 0
+	for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+		mig := m.migrations[i]
+		if mig.Version > current {
+			continue
+		}
+
+		var previous int64 = -1
+		if i > 0 {
+			previous = int64(m.migrations[i-1].Version)
+		}
+		if err := m.apply(ctx, mig.Down, previous); err != nil {
+			return reverted, fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+// Status lists every migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	current, _, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		statuses = append(statuses, Status{Migration: mig, Applied: mig.Version <= current})
+	}
+	return statuses, nil
+}
+
+// apply runs sql and records version in one transaction, so a failure leaves
+// the schema as it was. A negative version clears the table.
+func (m *Migrator) apply(ctx context.Context, sql string, version int64) error {
+	return pgx.BeginFunc(ctx, m.pool, func(tx pgx.Tx) error {
+		if strings.TrimSpace(sql) != "" {
+			if _, err := tx.Exec(ctx, sql); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM schema_migrations`); err != nil {
+			return err
+		}
+		if version < 0 {
+			return nil
+		}
+		_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, version)
+		return err
+	})
+}
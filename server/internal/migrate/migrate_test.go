@@ -0,0 +1,156 @@
+package migrate
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hrutav-modha/social-media-app/server/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("Embedded migrations are well formed", func(t *testing.T) {
+		migs, err := Load(migrations.FS)
+		require.NoError(t, err)
+		require.NotEmpty(t, migs)
+
+		assert.Equal(t, uint(1), migs[0].Version)
+		assert.Equal(t, "init", migs[0].Name)
+		for i := 1; i < len(migs); i++ {
+			assert.Greater(t, migs[i].Version, migs[i-1].Version)
+		}
+	})
+
+	t.Run("Sorted by version", func(t *testing.T) {
+		migs, err := Load(fstest.MapFS{
+			"000010_b.up.sql":   {Data: []byte("B")},
+			"000010_b.down.sql": {Data: []byte("-B")},
+			"000002_a.up.sql":   {Data: []byte("A")},
+			"000002_a.down.sql": {Data: []byte("-A")},
+			"README.md":         {Data: []byte("ignored")},
+		})
+		require.NoError(t, err)
+		require.Len(t, migs, 2)
+		assert.Equal(t, Migration{Version: 2, Name: "a", Up: "A", Down: "-A"}, migs[0])
+		assert.Equal(t, uint(10), migs[1].Version)
+	})
+
+	t.Run("Missing down file", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{"000001_a.up.sql": {Data: []byte("A")}})
+		assert.ErrorContains(t, err, "one up and one down")
+	})
+
+	t.Run("Conflicting names", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{
+			"000001_a.up.sql":   {Data: []byte("A")},
+			"000001_b.down.sql": {Data: []byte("B")},
+		})
+		assert.ErrorContains(t, err, "used by both")
+	})
+}
+
+func TestMigrator(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set, skipping test")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	m, err := New(pool, fstest.MapFS{
+		"000001_a.up.sql":   {Data: []byte("CREATE TABLE migrate_test_a (id INT)")},
+		"000001_a.down.sql": {Data: []byte("DROP TABLE migrate_test_a")},
+		"000002_b.up.sql":   {Data: []byte("CREATE TABLE migrate_test_b (id INT); INSERT INTO migrate_test_b VALUES (1)")},
+		"000002_b.down.sql": {Data: []byte("DROP TABLE migrate_test_b")},
+	})
+	require.NoError(t, err)
+
+	_, err = pool.Exec(ctx, `DROP TABLE IF EXISTS schema_migrations, migrate_test_a, migrate_test_b`)
+	require.NoError(t, err)
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, applied)
+
+	version, dirty, err := m.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), version)
+	assert.False(t, dirty)
+
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, applied)
+
+	reverted, err := m.Down(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reverted)
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+
+	reverted, err = m.Down(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reverted)
+
+	version, _, err = m.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), version)
+
+	t.Run("Concurrent migrators apply each migration once", func(t *testing.T) {
+		var wg sync.WaitGroup
+		results := make([]int, 4)
+		errs := make([]error, 4)
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], errs[i] = m.Up(ctx)
+			}()
+		}
+		wg.Wait()
+
+		total := 0
+		for i := range results {
+			require.NoError(t, errs[i])
+			total += results[i]
+		}
+		assert.Equal(t, 2, total)
+
+		pending, err := m.Check(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, pending)
+	})
+
+	t.Run("Newer schema is refused", func(t *testing.T) {
+		_, err := pool.Exec(ctx, `UPDATE schema_migrations SET version = 99`)
+		require.NoError(t, err)
+		defer pool.Exec(ctx, `UPDATE schema_migrations SET version = 2`)
+
+		_, err = m.Check(ctx)
+		assert.ErrorIs(t, err, ErrTooNew)
+		_, err = m.Up(ctx)
+		assert.ErrorIs(t, err, ErrTooNew)
+	})
+
+	t.Run("Dirty schema is refused", func(t *testing.T) {
+		_, err := pool.Exec(ctx, `UPDATE schema_migrations SET dirty = true`)
+		require.NoError(t, err)
+		defer pool.Exec(ctx, `UPDATE schema_migrations SET dirty = false`)
+
+		_, err = m.Check(ctx)
+		assert.ErrorIs(t, err, ErrDirty)
+	})
+
+	_, err = m.Down(ctx, 2)
+	require.NoError(t, err)
+}
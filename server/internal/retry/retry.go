@@ -0,0 +1,84 @@
+// Package retry runs operations with bounded exponential backoff.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff configures Do. Zero values fall back to sensible defaults.
+type Backoff struct {
+	// Initial is the delay before the first retry. Defaults to 500ms.
+	Initial time.Duration
+	// Max caps the delay between attempts. Defaults to 10s.
+	Max time.Duration
+	// Multiplier grows the delay after each attempt. Defaults to 2.
+	Multiplier float64
+	// MaxElapsed bounds the total time spent retrying. Zero means no bound
+	// other than ctx.
+	MaxElapsed time.Duration
+	// MaxAttempts bounds the number of attempts. Zero means unlimited.
+	MaxAttempts int
+}
+
+// Do calls op until it succeeds, ctx is done, or the backoff's limits are
+// reached, sleeping with jittered exponential backoff between attempts.
+// onRetry, if non-nil, is called before every sleep. The last error from op is
+// returned, wrapped with the number of attempts made.
+func Do(ctx context.Context, b Backoff, op func(context.Context) error, onRetry func(attempt int, delay time.Duration, err error)) error {
+	initial := b.Initial
+	if initial <= 0 {
+		initial = 500 * time.Millisecond
+	}
+	maxDelay := b.Max
+	if maxDelay <= 0 {
+		maxDelay = 10 * time.Second
+	}
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	var deadline time.Time
+	if b.MaxElapsed > 0 {
+		deadline = time.Now().Add(b.MaxElapsed)
+	}
+
+	delay := initial
+	for attempt := 1; ; attempt++ {
+		err := op(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if b.MaxAttempts > 0 && attempt >= b.MaxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		// Equal jitter: keep half the delay, randomise the other half, so
+		// replicas restarting together don't retry in lockstep.
+		sleep := delay/2 + rand.N(delay/2+1)
+		if !deadline.IsZero() && time.Now().Add(sleep).After(deadline) {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		if onRetry != nil {
+			onRetry(attempt, sleep, err)
+		}
+
+		timer := time.NewTimer(sleep)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		case <-timer.C:
+		}
+
+		delay = time.Duration(float64(delay) * multiplier)
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
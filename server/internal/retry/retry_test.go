@@ -0,0 +1,71 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDo(t *testing.T) {
+	errDown := errors.New("connection refused")
+
+	t.Run("Succeeds after transient failures", func(t *testing.T) {
+		attempts := 0
+		var delays []time.Duration
+
+		err := Do(context.Background(), Backoff{Initial: time.Millisecond, Max: 4 * time.Millisecond}, func(ctx context.Context) error {
+			attempts++
+			if attempts < 4 {
+				return errDown
+			}
+			return nil
+		}, func(attempt int, delay time.Duration, err error) {
+			delays = append(delays, delay)
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, attempts)
+		assert.Len(t, delays, 3)
+		for _, d := range delays {
+			assert.LessOrEqual(t, d, 4*time.Millisecond)
+		}
+	})
+
+	t.Run("Stops after MaxAttempts", func(t *testing.T) {
+		attempts := 0
+
+		err := Do(context.Background(), Backoff{Initial: time.Millisecond, MaxAttempts: 3}, func(ctx context.Context) error {
+			attempts++
+			return errDown
+		}, nil)
+
+		assert.ErrorIs(t, err, errDown)
+		assert.Contains(t, err.Error(), "after 3 attempts")
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("Stops at MaxElapsed", func(t *testing.T) {
+		start := time.Now()
+
+		err := Do(context.Background(), Backoff{Initial: 10 * time.Millisecond, MaxElapsed: 50 * time.Millisecond}, func(ctx context.Context) error {
+			return errDown
+		}, nil)
+
+		assert.ErrorIs(t, err, errDown)
+		assert.Less(t, time.Since(start), 200*time.Millisecond)
+	})
+
+	t.Run("Stops when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := Do(ctx, Backoff{Initial: time.Second}, func(ctx context.Context) error {
+			return errDown
+		}, nil)
+
+		assert.ErrorIs(t, err, errDown)
+	})
+}
@@ -0,0 +1,93 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Counts is the number of rows written per table.
+type Counts struct {
+	Users, Follows, Posts, Likes, Comments, Notifications int64
+}
+
+// Load bulk-inserts ds with COPY in a single transaction, so a failed seed
+// leaves the database untouched. Every user gets passwordHash, so they can
+// all log in with the same password. With reset, existing users and
+// everything that references them are deleted first.
+func Load(ctx context.Context, pool *pgxpool.Pool, ds *Dataset, passwordHash string, reset bool) (Counts, error) {
+	var counts Counts
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if reset {
+			if _, err := tx.Exec(ctx, `TRUNCATE users, notifications CASCADE`); err != nil {
+				return fmt.Errorf("failed to reset tables: %w", err)
+			}
+		}
+
+		var err error
+		if counts.Users, err = copyRows(ctx, tx, "users",
+			[]string{"id", "username", "email", "password_hash", "display_name", "bio", "created_at", "updated_at"},
+			ds.Users, func(u User) []any {
+				return []any{u.ID, u.Username, u.Email, passwordHash, u.DisplayName, nullable(u.Bio), u.CreatedAt, u.CreatedAt}
+			}); err != nil {
+			return err
+		}
+		if counts.Follows, err = copyRows(ctx, tx, "follows",
+			[]string{"follower_id", "following_id", "created_at"},
+			ds.Follows, func(f Follow) []any {
+				return []any{f.FollowerID, f.FollowingID, f.CreatedAt}
+			}); err != nil {
+			return err
+		}
+		if counts.Posts, err = copyRows(ctx, tx, "posts",
+			[]string{"id", "user_id", "content", "created_at", "updated_at"},
+			ds.Posts, func(p Post) []any {
+				return []any{p.ID, p.UserID, p.Content, p.CreatedAt, p.CreatedAt}
+			}); err != nil {
+			return err
+		}
+		if counts.Likes, err = copyRows(ctx, tx, "likes",
+			[]string{"user_id", "post_id", "created_at"},
+			ds.Likes, func(l Like) []any {
+				return []any{l.UserID, l.PostID, l.CreatedAt}
+			}); err != nil {
+			return err
+		}
+		if counts.Comments, err = copyRows(ctx, tx, "comments",
+			[]string{"id", "post_id", "user_id", "parent_id", "content", "created_at"},
+			ds.Comments, func(c Comment) []any {
+				return []any{c.ID, c.PostID, c.UserID, nullable(c.ParentID), c.Content, c.CreatedAt}
+			}); err != nil {
+			return err
+		}
+		if counts.Notifications, err = copyRows(ctx, tx, "notifications",
+			[]string{"id", "recipient_id", "actor_id", "type", "entity_id", "entity_type", "is_read", "created_at"},
+			ds.Notifications, func(n Notification) []any {
+				return []any{n.ID, n.RecipientID, n.ActorID, n.Type, n.EntityID, n.EntityType, n.IsRead, n.CreatedAt}
+			}); err != nil {
+			return err
+		}
+		return nil
+	})
+	return counts, err
+}
+
+func copyRows[T any](ctx context.Context, tx pgx.Tx, table string, columns []string, rows []T, values func(T) []any) (int64, error) {
+	n, err := tx.CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromSlice(len(rows), func(i int) ([]any, error) {
+		return values(rows[i]), nil
+	}))
+	if err != nil {
+		return n, fmt.Errorf("failed to copy %s: %w", table, err)
+	}
+	return n, nil
+}
+
+// nullable maps "" to NULL.
+func nullable(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,388 @@
+// Package seed generates a realistic, deterministic dataset for local
+// development and load tests: users with a power-law follower graph, posts
+// with hashtags and mentions, likes, threaded comments and the notifications
+// they would have produced.
+package seed
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Config controls the size and shape of the generated data.
+type Config struct {
+	// Seed makes the output reproducible: the same Config always yields the
+	// same rows.
+	Seed uint64
+	// Users is the number of accounts to create.
+	Users int
+	// AvgFollowing is the mean number of accounts each user follows.
+	AvgFollowing float64
+	// FollowSkew is the Zipf exponent (> 1) for picking whom to follow.
+	// Higher values concentrate followers on fewer accounts.
+	FollowSkew float64
+	// AvgPosts is the mean number of posts per user.
+	AvgPosts float64
+	// LikeRate is the fraction of an author's followers that like each post,
+	// on average.
+	LikeRate float64
+	// AvgComments is the mean number of comments per post.
+	AvgComments float64
+	// ReplyRate is the probability that a comment replies to an earlier one.
+	ReplyRate float64
+	// Until is the newest timestamp generated; activity spans the Span
+	// before it.
+	Until time.Time
+	Span  time.Duration
+}
+
+// DefaultConfig returns a small graph suitable for local development.
+func DefaultConfig() Config {
+	return Config{
+		Seed:         1,
+		Users:        1000,
+		AvgFollowing: 40,
+		FollowSkew:   1.3,
+		AvgPosts:     10,
+		LikeRate:     0.1,
+		AvgComments:  2,
+		ReplyRate:    0.4,
+		Until:        time.Now().UTC().Truncate(24 * time.Hour),
+		Span:         90 * 24 * time.Hour,
+	}
+}
+
+// Validate reports the first invalid setting.
+func (c Config) Validate() error {
+	switch {
+	case c.Users < 2:
+		return fmt.Errorf("users must be at least 2")
+	case c.AvgFollowing < 0 || c.AvgPosts < 0 || c.AvgComments < 0:
+		return fmt.Errorf("averages must not be negative")
+	case c.FollowSkew <= 1:
+		return fmt.Errorf("follow skew must be greater than 1")
+	case c.LikeRate < 0 || c.LikeRate > 1 || c.ReplyRate < 0 || c.ReplyRate > 1:
+		return fmt.Errorf("like and reply rates must be between 0 and 1")
+	case c.Span <= 0:
+		return fmt.Errorf("span must be positive")
+	}
+	return nil
+}
+
+// User is a row of the users table.
+type User struct {
+	ID          string
+	Username    string
+	Email       string
+	DisplayName string
+	Bio         string
+	CreatedAt   time.Time
+}
+
+// Follow is a row of the follows table.
+type Follow struct {
+	FollowerID  string
+	FollowingID string
+	CreatedAt   time.Time
+}
+
+// Post is a row of the posts table.
+type Post struct {
+	ID        string
+	UserID    string
+	Content   string
+	CreatedAt time.Time
+}
+
+// Like is a row of the likes table.
+type Like struct {
+	UserID    string
+	PostID    string
+	CreatedAt time.Time
+}
+
+// Comment is a row of the comments table. ParentID is empty for top-level
+// comments; parents always come before their replies.
+type Comment struct {
+	ID        string
+	PostID    string
+	UserID    string
+	ParentID  string
+	Content   string
+	CreatedAt time.Time
+}
+
+// Notification is a row of the notifications table.
+type Notification struct {
+	ID          string
+	RecipientID string
+	ActorID     string
+	Type        string
+	EntityID    string
+	EntityType  string
+	IsRead      bool
+	CreatedAt   time.Time
+}
+
+// Dataset is everything Generate produced, in insertion order.
+type Dataset struct {
+	Users         []User
+	Follows       []Follow
+	Posts         []Post
+	Likes         []Like
+	Comments      []Comment
+	Notifications []Notification
+}
+
+// generator holds the state shared by the generation steps.
+type generator struct {
+	cfg Config
+	rng *rand.Rand
+	ds  *Dataset
+
+	start      time.Time
+	followers  [][]int // user index -> follower indexes
+	following  [][]int // user index -> followed indexes
+	byID       map[string]int
+	byUsername map[string]int
+}
+
+// Generate builds a dataset from cfg. The result depends only on cfg.
+func Generate(cfg Config) (*Dataset, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		cfg:        cfg,
+		rng:        rand.New(rand.NewPCG(cfg.Seed, cfg.Seed^0x9e3779b97f4a7c15)),
+		ds:         &Dataset{},
+		start:      cfg.Until.Add(-cfg.Span),
+		followers:  make([][]int, cfg.Users),
+		following:  make([][]int, cfg.Users),
+		byID:       make(map[string]int, cfg.Users),
+		byUsername: make(map[string]int, cfg.Users),
+	}
+
+	g.users()
+	g.follows()
+	g.posts()
+	g.likesAndComments()
+	return g.ds, nil
+}
+
+// uuid returns a random version 4 UUID drawn from the generator's source.
+func (g *generator) uuid() string {
+	var b [16]byte
+	for i := 0; i < 16; i += 8 {
+		v := g.rng.Uint64()
+		for j := 0; j < 8; j++ {
+			b[i+j] = byte(v >> (8 * j))
+		}
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// between returns a time uniformly distributed in [from, cfg.Until).
+func (g *generator) between(from time.Time) time.Time {
+	window := g.cfg.Until.Sub(from)
+	if window <= 0 {
+		return from
+	}
+	return from.Add(time.Duration(g.rng.Int64N(int64(window)))).Truncate(time.Microsecond)
+}
+
+// count draws a non-negative count with the given mean from a geometric
+// distribution, which gives the long tail real activity has.
+func (g *generator) count(mean float64) int {
+	if mean <= 0 {
+		return 0
+	}
+	return int(math.Floor(g.rng.ExpFloat64() * mean))
+}
+
+func (g *generator) users() {
+	for i := 0; i < g.cfg.Users; i++ {
+		first := firstNames[g.rng.IntN(len(firstNames))]
+		last := lastNames[g.rng.IntN(len(lastNames))]
+		username := fmt.Sprintf("%s%d", strings.ToLower(first), i+1)
+
+		var bio string
+		if g.rng.Float64() < 0.7 {
+			bio = g.sentence(4 + g.rng.IntN(8))
+		}
+
+		// Early accounts sign up in the first third of the span so there is
+		// history to fill the feed with.
+		created := g.start.Add(time.Duration(g.rng.Int64N(int64(g.cfg.Span / 3)))).Truncate(time.Microsecond)
+
+		u := User{
+			ID:          g.uuid(),
+			Username:    username,
+			Email:       username + "@example.com",
+			DisplayName: first + " " + last,
+			Bio:         bio,
+			CreatedAt:   created,
+		}
+		g.byID[u.ID] = i
+		g.byUsername[u.Username] = i
+		g.ds.Users = append(g.ds.Users, u)
+	}
+}
+
+// follows builds the social graph. Whom to follow is drawn from a Zipf
+// distribution over a random popularity ranking, so a few accounts collect
+// most of the followers, as on real networks.
+func (g *generator) follows() {
+	n := g.cfg.Users
+	rank := g.rng.Perm(n)
+	zipf := rand.NewZipf(g.rng, g.cfg.FollowSkew, 1, uint64(n-1))
+
+	for i := 0; i < n; i++ {
+		want := g.count(g.cfg.AvgFollowing)
+		if want > n-1 {
+			want = n - 1
+		}
+
+		seen := make(map[int]bool, want)
+		for attempts := 0; len(seen) < want && attempts < want*4; attempts++ {
+			target := rank[zipf.Uint64()]
+			if target == i || seen[target] {
+				continue
+			}
+			seen[target] = true
+
+			follower, followed := g.ds.Users[i], g.ds.Users[target]
+			at := g.between(later(follower.CreatedAt, followed.CreatedAt))
+			g.ds.Follows = append(g.ds.Follows, Follow{FollowerID: follower.ID, FollowingID: followed.ID, CreatedAt: at})
+			g.followers[target] = append(g.followers[target], i)
+			g.following[i] = append(g.following[i], target)
+			g.notify(followed.ID, follower.ID, "follow", follower.ID, "user", at)
+		}
+	}
+}
+
+func (g *generator) posts() {
+	for i, u := range g.ds.Users {
+		for range g.count(g.cfg.AvgPosts) {
+			g.ds.Posts = append(g.ds.Posts, Post{
+				ID:        g.uuid(),
+				UserID:    u.ID,
+				Content:   g.content(i, maxPostLength),
+				CreatedAt: g.between(u.CreatedAt),
+			})
+		}
+	}
+	sort.SliceStable(g.ds.Posts, func(a, b int) bool {
+		return g.ds.Posts[a].CreatedAt.Before(g.ds.Posts[b].CreatedAt)
+	})
+
+	for _, p := range g.ds.Posts {
+		g.mentions(p.Content, g.byID[p.UserID], p.ID, "post", p.CreatedAt)
+	}
+}
+
+func (g *generator) likesAndComments() {
+	for _, p := range g.ds.Posts {
+		author := g.byID[p.UserID]
+		followers := g.followers[author]
+
+		// Most engagement comes from the author's followers, with a trickle
+		// from everyone else.
+		liked := make(map[int]bool)
+		for _, f := range followers {
+			if g.rng.Float64() < g.cfg.LikeRate {
+				liked[f] = true
+			}
+		}
+		for range g.count(g.cfg.LikeRate * 2) {
+			liked[g.rng.IntN(g.cfg.Users)] = true
+		}
+		likers := make([]int, 0, len(liked))
+		for u := range liked {
+			likers = append(likers, u)
+		}
+		sort.Ints(likers)
+
+		for _, u := range likers {
+			at := g.between(later(p.CreatedAt, g.ds.Users[u].CreatedAt))
+			g.ds.Likes = append(g.ds.Likes, Like{UserID: g.ds.Users[u].ID, PostID: p.ID, CreatedAt: at})
+			if u != author {
+				g.notify(p.UserID, g.ds.Users[u].ID, "like", p.ID, "post", at)
+			}
+		}
+
+		var thread []Comment
+		for range g.count(g.cfg.AvgComments) {
+			commenter := g.rng.IntN(g.cfg.Users)
+			if len(followers) > 0 && g.rng.Float64() < 0.8 {
+				commenter = followers[g.rng.IntN(len(followers))]
+			}
+
+			c := Comment{
+				ID:      g.uuid(),
+				PostID:  p.ID,
+				UserID:  g.ds.Users[commenter].ID,
+				Content: g.content(commenter, maxCommentLength),
+			}
+			after := later(p.CreatedAt, g.ds.Users[commenter].CreatedAt)
+
+			recipient := p.UserID
+			if len(thread) > 0 && g.rng.Float64() < g.cfg.ReplyRate {
+				parent := thread[g.rng.IntN(len(thread))]
+				c.ParentID = parent.ID
+				after = later(after, parent.CreatedAt)
+				recipient = parent.UserID
+			}
+			c.CreatedAt = g.between(after)
+
+			thread = append(thread, c)
+			g.ds.Comments = append(g.ds.Comments, c)
+			if recipient != c.UserID {
+				g.notify(recipient, c.UserID, "comment", c.ID, "comment", c.CreatedAt)
+			}
+			g.mentions(c.Content, commenter, c.ID, "comment", c.CreatedAt)
+		}
+	}
+}
+
+// notify records a notification. Older ones are more likely to have been
+// read.
+func (g *generator) notify(recipient, actor, typ, entityID, entityType string, at time.Time) {
+	age := float64(g.cfg.Until.Sub(at)) / float64(g.cfg.Span)
+	g.ds.Notifications = append(g.ds.Notifications, Notification{
+		ID:          g.uuid(),
+		RecipientID: recipient,
+		ActorID:     actor,
+		Type:        typ,
+		EntityID:    entityID,
+		EntityType:  entityType,
+		IsRead:      g.rng.Float64() < 0.2+0.8*age,
+		CreatedAt:   at,
+	})
+}
+
+// mentions notifies every user mentioned in content.
+func (g *generator) mentions(content string, author int, entityID, entityType string, at time.Time) {
+	for _, word := range strings.Fields(content) {
+		if !strings.HasPrefix(word, "@") {
+			continue
+		}
+		if u, ok := g.byUsername[strings.TrimRight(word[1:], ".,!?")]; ok && u != author {
+			g.notify(g.ds.Users[u].ID, g.ds.Users[author].ID, "mention", entityID, entityType, at)
+		}
+	}
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
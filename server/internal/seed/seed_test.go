@@ -0,0 +1,135 @@
+package seed
+
+import (
+	"context"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) { os.Exit(testutil.Main(m)) }
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.Users = 300
+	cfg.Until = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return cfg
+}
+
+func TestGenerate(t *testing.T) {
+	cfg := testConfig()
+	ds, err := Generate(cfg)
+	require.NoError(t, err)
+
+	t.Run("Deterministic from the seed", func(t *testing.T) {
+		again, err := Generate(cfg)
+		require.NoError(t, err)
+		assert.Equal(t, ds, again)
+
+		cfg := cfg
+		cfg.Seed++
+		other, err := Generate(cfg)
+		require.NoError(t, err)
+		assert.NotEqual(t, ds.Users[0].ID, other.Users[0].ID)
+	})
+
+	t.Run("Every table is populated", func(t *testing.T) {
+		assert.Len(t, ds.Users, cfg.Users)
+		assert.NotEmpty(t, ds.Follows)
+		assert.NotEmpty(t, ds.Posts)
+		assert.NotEmpty(t, ds.Likes)
+		assert.NotEmpty(t, ds.Comments)
+
+		types := make(map[string]bool)
+		for _, n := range ds.Notifications {
+			types[n.Type] = true
+			assert.NotEqual(t, n.RecipientID, n.ActorID)
+		}
+		for _, typ := range []string{"follow", "like", "comment", "mention"} {
+			assert.True(t, types[typ], "no %s notifications", typ)
+		}
+	})
+
+	t.Run("Rows satisfy the schema constraints", func(t *testing.T) {
+		usernames := make(map[string]bool)
+		for _, u := range ds.Users {
+			assert.False(t, usernames[u.Username], "duplicate username %s", u.Username)
+			usernames[u.Username] = true
+		}
+
+		follows := make(map[[2]string]bool)
+		for _, f := range ds.Follows {
+			assert.NotEqual(t, f.FollowerID, f.FollowingID)
+			key := [2]string{f.FollowerID, f.FollowingID}
+			assert.False(t, follows[key], "duplicate follow")
+			follows[key] = true
+		}
+
+		likes := make(map[[2]string]bool)
+		for _, l := range ds.Likes {
+			key := [2]string{l.UserID, l.PostID}
+			assert.False(t, likes[key], "duplicate like")
+			likes[key] = true
+		}
+
+		for _, p := range ds.Posts {
+			assert.LessOrEqual(t, len(p.Content), maxPostLength)
+			assert.True(t, p.CreatedAt.Before(cfg.Until))
+		}
+
+		comments := make(map[string]Comment)
+		for _, c := range ds.Comments {
+			assert.LessOrEqual(t, len(c.Content), maxCommentLength)
+			if c.ParentID != "" {
+				parent, ok := comments[c.ParentID]
+				require.True(t, ok, "reply before its parent")
+				assert.Equal(t, parent.PostID, c.PostID)
+				assert.False(t, c.CreatedAt.Before(parent.CreatedAt))
+			}
+			comments[c.ID] = c
+		}
+	})
+
+	t.Run("Followers follow a power law", func(t *testing.T) {
+		counts := make(map[string]int)
+		for _, f := range ds.Follows {
+			counts[f.FollowingID]++
+		}
+		var sorted []int
+		for _, u := range ds.Users {
+			sorted = append(sorted, counts[u.ID])
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+
+		median := sorted[len(sorted)/2]
+		assert.Greater(t, sorted[0], 10*max(median, 1), "top account should dwarf the median")
+	})
+
+	t.Run("Invalid config", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.FollowSkew = 1
+		_, err := Generate(cfg)
+		assert.Error(t, err)
+	})
+}
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	pool := testutil.DB(t)
+
+	cfg := testConfig()
+	cfg.Users = 50
+	ds, err := Generate(cfg)
+	require.NoError(t, err)
+
+	counts, err := Load(ctx, pool, ds, "hash", true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(ds.Users)), counts.Users)
+	assert.Equal(t, int64(len(ds.Comments)), counts.Comments)
+	assert.Equal(t, int64(len(ds.Notifications)), counts.Notifications)
+}
@@ -0,0 +1,90 @@
+package seed
+
+import (
+	"strings"
+)
+
+// Limits from the posts and comments check constraints.
+const (
+	maxPostLength    = 500
+	maxCommentLength = 300
+)
+
+var firstNames = []string{
+	"Ada", "Alan", "Amara", "Ana", "Arjun", "Ben", "Bianca", "Carlos", "Chen", "Chloe",
+	"Dara", "David", "Elena", "Emeka", "Emma", "Farah", "Felix", "Grace", "Hana", "Hugo",
+	"Ines", "Isaac", "Jamal", "Jin", "Julia", "Kai", "Kemal", "Lara", "Leo", "Lina",
+	"Luca", "Maya", "Mei", "Mila", "Nadia", "Noah", "Omar", "Olga", "Priya", "Quinn",
+	"Rafael", "Rosa", "Sam", "Sara", "Sofia", "Tariq", "Theo", "Uma", "Victor", "Wen",
+	"Yara", "Yusuf", "Zara", "Zoe",
+}
+
+var lastNames = []string{
+	"Adeyemi", "Alvarez", "Berg", "Chen", "Costa", "Dubois", "Fischer", "Garcia", "Haddad", "Ito",
+	"Jensen", "Kim", "Kowalski", "Lee", "Lopez", "Mensah", "Moreau", "Nakamura", "Novak", "Okafor",
+	"Patel", "Petrov", "Rossi", "Santos", "Schmidt", "Silva", "Singh", "Tanaka", "Varga", "Wang",
+}
+
+var words = []string{
+	"today", "finally", "really", "just", "shipped", "coffee", "morning", "weekend", "project", "team",
+	"learned", "something", "new", "about", "the", "a", "this", "that", "with", "and",
+	"love", "hate", "trying", "out", "great", "terrible", "idea", "thread", "photo", "walk",
+	"city", "rain", "sunny", "music", "album", "book", "reading", "writing", "code", "bug",
+	"fixed", "release", "launch", "dinner", "friends", "travel", "train", "flight", "home", "late",
+	"early", "again", "never", "always", "maybe", "honestly", "wow", "thanks", "everyone", "question",
+}
+
+// hashtags are ordered by popularity; content draws from them with a skew.
+var hashtags = []string{
+	"golang", "photography", "coffee", "travel", "music", "books", "running", "design", "food", "gaming",
+	"opensource", "startups", "art", "science", "football", "cooking", "hiking", "movies", "cats", "dogs",
+	"linux", "rust", "webdev", "android", "ios", "ux", "climate", "space", "history", "poetry",
+}
+
+// sentence returns n random words, capitalised and ending in a full stop.
+func (g *generator) sentence(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		w := words[g.rng.IntN(len(words))]
+		if i == 0 {
+			w = strings.ToUpper(w[:1]) + w[1:]
+		} else {
+			b.WriteByte(' ')
+		}
+		b.WriteString(w)
+	}
+	b.WriteByte('.')
+	return b.String()
+}
+
+// content writes a post or comment by author: a few sentences, sometimes a
+// mention of someone they follow and a couple of hashtags, within maxLen.
+func (g *generator) content(author, maxLen int) string {
+	parts := []string{g.sentence(3 + g.rng.IntN(12))}
+	if g.rng.Float64() < 0.3 {
+		parts = append(parts, g.sentence(3+g.rng.IntN(10)))
+	}
+
+	if follows := g.following[author]; len(follows) > 0 && g.rng.Float64() < 0.2 {
+		parts = append(parts, "@"+g.ds.Users[follows[g.rng.IntN(len(follows))]].Username)
+	}
+
+	for range g.rng.IntN(3) {
+		// Squaring a uniform draw favours the front of the list.
+		f := g.rng.Float64()
+		parts = append(parts, "#"+hashtags[int(f*f*float64(len(hashtags)))])
+	}
+
+	out := ""
+	for _, p := range parts {
+		next := p
+		if out != "" {
+			next = out + " " + p
+		}
+		if len(next) > maxLen {
+			break
+		}
+		out = next
+	}
+	return out
+}
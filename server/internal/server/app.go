@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/auth"
+	"github.com/hrutav-modha/social-media-app/server/internal/config"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/health"
+	"github.com/hrutav-modha/social-media-app/server/internal/users"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/minio/minio-go/v7"
+	"github.com/redis/go-redis/v9"
+)
+
+// App owns the configuration, the clients and the services built on them,
+// and hands each handler its dependencies explicitly. Handlers that touch the
+// database get Store, never the pool, so tests can fake it. Nothing lives in
+// package globals, so several Apps can run side by side in one process.
+type App struct {
+	Config *config.Config
+	DB     *pgxpool.Pool
+	Redis  *redis.Client
+	Minio  *minio.Client
+
+	Clock    auth.Clock
+	Store    db.Store
+	Checker  *health.Checker
+	CORS     *CORSPolicies
+	Tokens   *auth.TokenIssuer
+	Sessions *auth.SessionStore
+
+	// Suggestions precomputes follow suggestions into Redis; cmd/api runs
+	// its periodic refresh.
+	Suggestions *users.Suggestions
+	// Accounts deactivates and deletes accounts; cmd/api runs its purge of
+	// the ones past their grace period.
+	Accounts *users.Accounts
+}
+
+// Deps are the clients an App is built on. Clock and Random default to the
+// wall clock and crypto/rand.
+type Deps struct {
+	DB    *pgxpool.Pool
+	Redis *redis.Client
+	Minio *minio.Client
+
+	Clock  auth.Clock
+	Random io.Reader
+}
+
+// New builds an App from cfg and deps. Readiness checks are registered for
+// every client given; MinIO's is optional, since only media routes need it.
+func New(cfg *config.Config, deps Deps) (*App, error) {
+	if deps.Clock == nil {
+		deps.Clock = auth.SystemClock
+	}
+	if deps.Random == nil {
+		deps.Random = rand.Reader
+	}
+
+	tokens, err := auth.NewTokenIssuer(cfg.JWTPrivateKey, cfg.JWTPublicKey, deps.Clock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize JWT: %w", err)
+	}
+
+	a := &App{
+		Config:   cfg,
+		DB:       deps.DB,
+		Redis:    deps.Redis,
+		Minio:    deps.Minio,
+		Clock:    deps.Clock,
+		Checker:  health.NewChecker(cfg.HealthCheckTimeout, cfg.HealthCacheTTL),
+		CORS:     NewCORSPolicies(cfg),
+		Tokens:   tokens,
+		Sessions: auth.NewSessionStore(deps.Redis, deps.Clock, deps.Random),
+	}
+
+	if a.DB != nil {
+		a.Store = db.NewStore(a.DB)
+		a.Checker.Register("postgres", a.DB.Ping)
+	}
+	a.Suggestions = users.NewSuggestions(a.Store, deps.Redis, deps.Clock)
+	a.Accounts = users.NewAccounts(a.Store, a.Sessions, a.media(), deps.Clock)
+	if a.Redis != nil {
+		a.Checker.Register("redis", func(ctx context.Context) error {
+			return a.Redis.Ping(ctx).Err()
+		})
+	}
+	if a.Minio != nil {
+		a.Checker.RegisterOptional("minio", CheckMinio(a.Minio))
+	}
+	return a, nil
+}
+
+// media is where profile images are kept.
+func (a *App) media() users.Media {
+	return users.Media{
+		Client:  a.Minio,
+		Bucket:  MediaBucket,
+		BaseURL: a.Config.MediaBaseURL,
+	}
+}
+
+// CheckMinio returns a check that passes once the media bucket exists.
+func CheckMinio(client *minio.Client) func(context.Context) error {
+	return func(ctx context.Context) error {
+		ok, err := client.BucketExists(ctx, MediaBucket)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("bucket %q does not exist", MediaBucket)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,158 @@
+// Package server assembles the HTTP API: the App that owns its dependencies,
+// the middleware stack, the route groups and the CORS policies they use.
+// cmd/api builds an App from real clients; tests build one from stand-ins.
+package server
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hrutav-modha/social-media-app/server/internal/auth"
+	"github.com/hrutav-modha/social-media-app/server/internal/config"
+	customMiddleware "github.com/hrutav-modha/social-media-app/server/internal/middleware"
+	"github.com/hrutav-modha/social-media-app/server/internal/users"
+)
+
+// MediaBucket is the MinIO bucket created by docker-compose for user uploads.
+const MediaBucket = "media"
+
+// CORSPolicies holds the CORS middlewares, whose allowed origins follow the
+// configuration when it is reloaded.
+type CORSPolicies struct {
+	auth   *customMiddleware.ReloadableCORS
+	public *customMiddleware.ReloadableCORS
+}
+
+// NewCORSPolicies returns policies initialised from cfg.
+func NewCORSPolicies(cfg *config.Config) *CORSPolicies {
+	p := &CORSPolicies{
+		auth:   customMiddleware.NewReloadableCORS(customMiddleware.CORSPolicy{}),
+		public: customMiddleware.NewReloadableCORS(customMiddleware.CORSPolicy{}),
+	}
+	p.Update(cfg)
+	return p
+}
+
+// Update applies cfg's origins. The auth routes carry the refresh-token
+// cookie, so they get a credentialed policy limited to our own front-ends.
+// The rest of the API is authorised by bearer token and is readable from any
+// configured origin.
+func (p *CORSPolicies) Update(cfg *config.Config) {
+	p.auth.Update(customMiddleware.CORSPolicy{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowCredentials: true,
+	})
+	p.public.Update(customMiddleware.CORSPolicy{
+		AllowedOrigins: cfg.CORSPublicOrigins,
+	})
+}
+
+// Router builds the API's handler. The app's checker backs the probes and
+// gates the media routes on MinIO.
+func (a *App) Router() *chi.Mux {
+	cfg, checker, cors := a.Config, a.Checker, a.CORS
+
+	r := chi.NewRouter()
+	r.Use(customMiddleware.RequestID)
+	r.Use(customMiddleware.RealIP(cfg.TrustedProxies))
+	r.Use(customMiddleware.RequestLogger(customMiddleware.LoggerConfig{
+		SuccessSampleRate: cfg.LogSuccessSampleRate,
+	}))
+	r.Use(customMiddleware.Recoverer)
+	r.Use(customMiddleware.SecurityHeaders(customMiddleware.SecurityHeadersConfig{
+		HSTSMaxAge:            cfg.HSTSMaxAge,
+		ContentSecurityPolicy: cfg.CSPPolicy,
+		CSPReportOnly:         cfg.CSPReportOnly,
+	}))
+	r.Use(customMiddleware.ETag)
+	r.Use(customMiddleware.Compress(customMiddleware.DefaultCompressMinSize))
+
+	authHandler := auth.NewAuthHandler(a.Tokens, a.Sessions)
+	usersHandler := users.NewHandler(a.Store, a.Clock, a.media(), a.Suggestions, a.Accounts)
+
+	r.Route("/api/v1/auth", func(r chi.Router) {
+		r.Use(cors.auth.Handler)
+		r.Use(customMiddleware.Timeout(cfg.APITimeout))
+		r.Use(customMiddleware.MaxBytes(cfg.MaxJSONBodyBytes))
+
+		r.Post("/refresh", authHandler.Refresh)
+		r.With(customMiddleware.Auth(a.Tokens)).Post("/logout", authHandler.Logout)
+	})
+
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(cors.public.Handler)
+
+		// JSON routes.
+		r.Group(func(r chi.Router) {
+			r.Use(customMiddleware.Timeout(cfg.APITimeout))
+			r.Use(customMiddleware.MaxBytes(cfg.MaxJSONBodyBytes))
+
+			// Public reads; signed-in viewers also see how they relate to
+			// what they read.
+			r.Group(func(r chi.Router) {
+				r.Use(customMiddleware.OptionalAuth(a.Tokens))
+
+				r.Get("/users/search", usersHandler.SearchUsers)
+				r.Get("/users/{username}", usersHandler.GetProfile)
+				r.Get("/users/{username}/followers", usersHandler.ListFollowers)
+				r.Get("/users/{username}/following", usersHandler.ListFollowing)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(customMiddleware.Auth(a.Tokens))
+
+				// A deactivated account can still be restored or deleted.
+				r.Delete("/users/me", usersHandler.DeleteAccount)
+				r.Post("/users/me/deactivate", usersHandler.DeactivateAccount)
+				r.Post("/users/me/restore", usersHandler.RestoreAccount)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(customMiddleware.Auth(a.Tokens))
+				r.Use(customMiddleware.RequireActive(a.Accounts.Active))
+
+				r.Patch("/users/me", usersHandler.UpdateProfile)
+				r.Patch("/users/me/username", usersHandler.ChangeUsername)
+				r.Get("/users/suggestions", usersHandler.ListSuggestions)
+				r.Delete("/users/suggestions/{username}", usersHandler.DismissSuggestion)
+				r.Get("/users/me/follow-requests", usersHandler.ListFollowRequests)
+				r.Post("/users/me/follow-requests/{username}/approve", usersHandler.ApproveFollowRequest)
+				r.Post("/users/me/follow-requests/{username}/reject", usersHandler.RejectFollowRequest)
+				r.Get("/users/{username}/mutuals", usersHandler.ListMutuals)
+				r.Post("/users/{username}/follow", usersHandler.Follow)
+				r.Delete("/users/{username}/follow", usersHandler.Unfollow)
+				r.Post("/users/{username}/block", usersHandler.Block)
+				r.Delete("/users/{username}/block", usersHandler.Unblock)
+				r.Post("/users/{username}/mute", usersHandler.Mute)
+				r.Delete("/users/{username}/mute", usersHandler.Unmute)
+			})
+		})
+
+		// Media uploads. These answer 503 while MinIO is down instead of
+		// taking the whole API with them.
+		r.Group(func(r chi.Router) {
+			r.Use(checker.Require("minio"))
+			r.Use(customMiddleware.Timeout(cfg.UploadTimeout))
+			r.Use(customMiddleware.MaxBytes(cfg.MaxUploadBodyBytes))
+			r.Use(customMiddleware.Auth(a.Tokens))
+			r.Use(customMiddleware.RequireActive(a.Accounts.Active))
+
+			r.Post("/users/me/avatar", usersHandler.UploadAvatar)
+			r.Post("/users/me/banner", usersHandler.UploadBanner)
+		})
+	})
+
+	r.With(customMiddleware.MaxBytes(cfg.MaxJSONBodyBytes)).Post(customMiddleware.CSPReportPath, customMiddleware.CSPReport)
+
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Social Media App API is running!"))
+	})
+
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+	r.Get("/livez", checker.Liveness)
+	r.Get("/readyz", checker.Readiness)
+	return r
+}
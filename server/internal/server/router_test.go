@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/auth"
+	"github.com/hrutav-modha/social-media-app/server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestApp returns an App with fresh signing keys and no clients.
+func newTestApp(t *testing.T) *App {
+	t.Helper()
+	priv, pub, err := auth.GenerateKeyPair(auth.KeyEd25519, 0)
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		JWTPrivateKey:      priv,
+		JWTPublicKey:       pub,
+		CORSAllowedOrigins: []string{"http://localhost:3000"},
+		CORSPublicOrigins:  []string{"*"},
+		HealthCheckTimeout: time.Second,
+	}
+	app, err := New(cfg, Deps{})
+	require.NoError(t, err)
+	return app
+}
+
+func TestRouter(t *testing.T) {
+	app := newTestApp(t)
+	app.Checker.Register("postgres", func(ctx context.Context) error { return nil })
+	router := app.Router()
+
+	t.Run("Root endpoint returns 200", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Social Media App API is running!", w.Body.String())
+	})
+
+	t.Run("Health check returns 200", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/health", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "OK", w.Body.String())
+	})
+
+	t.Run("Liveness probe returns 200", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/livez", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Readiness probe reports components", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/readyz", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"postgres":{"status":"up"`)
+	})
+
+	t.Run("RequestID header is present in response", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/health", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.NotEmpty(t, w.Header().Get("X-Request-ID"))
+	})
+	t.Run("Security headers are present in response", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/health", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	})
+
+	t.Run("CSP report endpoint accepts reports", func(t *testing.T) {
+		body := strings.NewReader(`{"csp-report":{"blocked-uri":"inline"}}`)
+		req, _ := http.NewRequest("POST", "/csp-report", body)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+	t.Run("Auth routes use the credentialed CORS policy", func(t *testing.T) {
+		req, _ := http.NewRequest("OPTIONS", "/api/v1/auth/refresh", nil)
+		req.Header.Set("Origin", "http://localhost:3000")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "http://localhost:3000", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+
+		req, _ = http.NewRequest("OPTIONS", "/api/v1/auth/refresh", nil)
+		req.Header.Set("Origin", "https://elsewhere.io")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Public API routes use the open CORS policy", func(t *testing.T) {
+		req, _ := http.NewRequest("OPTIONS", "/api/v1/users/alice", nil)
+		req.Header.Set("Origin", "https://elsewhere.io")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	})
+}
+
+func TestNew(t *testing.T) {
+	t.Run("Invalid keys are rejected", func(t *testing.T) {
+		_, err := New(&config.Config{JWTPrivateKey: "nope", JWTPublicKey: "nope"}, Deps{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Apps are isolated from each other", func(t *testing.T) {
+		first, second := newTestApp(t), newTestApp(t)
+		token, err := first.Tokens.GenerateAccessToken("user-1")
+		require.NoError(t, err)
+
+		logout := func(app *App) int {
+			req, _ := http.NewRequest("POST", "/api/v1/auth/logout", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			app.Router().ServeHTTP(w, req)
+			return w.Code
+		}
+		assert.Equal(t, http.StatusNoContent, logout(first))
+		assert.Equal(t, http.StatusUnauthorized, logout(second))
+	})
+}
@@ -0,0 +1,47 @@
+package testutil
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// Clock is a fake auth.Clock that only moves when told to. Redis servers
+// attached with Follow are fast-forwarded along with it, so Redis TTLs and
+// the application's notion of time stay in step.
+type Clock struct {
+	mu    sync.Mutex
+	now   time.Time
+	redis []*miniredis.Miniredis
+}
+
+// NewClock returns a clock stopped at start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the clock's current time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, mr := range c.redis {
+		mr.FastForward(d)
+	}
+}
+
+// Follow makes mr's TTLs expire as the clock advances.
+func (c *Clock) Follow(mr *miniredis.Miniredis) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	mr.SetTime(c.now)
+	c.redis = append(c.redis, mr)
+}
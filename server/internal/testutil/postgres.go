@@ -0,0 +1,192 @@
+package testutil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/migrate"
+	"github.com/hrutav-modha/social-media-app/server/migrations"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// errNoPostgres means neither TEST_DB_URL nor a postgres installation is
+// available.
+var errNoPostgres = errors.New("no Postgres for integration tests: set TEST_DB_URL or put initdb and pg_ctl on PATH (or in TEST_PG_BIN)")
+
+// extensions are installed into the public schema once, because an extension
+// exists only once per database and test schemas are dropped with everything
+// in them.
+var extensions = []string{"pgcrypto", "pg_trgm", "unaccent"}
+
+// pg is the Postgres shared by every test in the process.
+var pg struct {
+	once  sync.Once
+	err   error
+	url   string
+	admin *pgxpool.Pool
+	stop  func()
+}
+
+// DB returns a pool connected to a fresh schema with every migration
+// applied. The schema is dropped when the test ends, so tests can run in
+// parallel without seeing each other's rows.
+//
+// The database comes from TEST_DB_URL or, when that is unset, from a
+// postgres server started for this test binary. Without either the test is
+// skipped.
+func DB(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+	pg.once.Do(setupPostgres)
+	if errors.Is(pg.err, errNoPostgres) {
+		unavailable(t, "%v", pg.err)
+	}
+	if pg.err != nil {
+		t.Fatalf("failed to set up Postgres: %v", pg.err)
+	}
+
+	ctx := context.Background()
+	schema := "test_" + randomHex(8)
+	if _, err := pg.admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		pg.admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	})
+
+	cfg, err := pgxpool.ParseConfig(pg.url)
+	if err != nil {
+		t.Fatalf("invalid database URL: %v", err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema + ",public"
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to connect to Postgres: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	m, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return pool
+}
+
+func setupPostgres() {
+	pg.url = os.Getenv("TEST_DB_URL")
+	if pg.url == "" {
+		pg.url, pg.stop, pg.err = startPostgres()
+		if pg.err != nil {
+			return
+		}
+	}
+
+	ctx := context.Background()
+	pg.admin, pg.err = pgxpool.New(ctx, pg.url)
+	if pg.err != nil {
+		return
+	}
+	for _, ext := range extensions {
+		_, err := pg.admin.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS "+pgx.Identifier{ext}.Sanitize()+" SCHEMA public")
+		if err != nil {
+			pg.err = fmt.Errorf("failed to install extension %s: %w", ext, err)
+			return
+		}
+	}
+}
+
+func shutdownPostgres() {
+	if pg.admin != nil {
+		pg.admin.Close()
+	}
+	if pg.stop != nil {
+		pg.stop()
+	}
+}
+
+// startPostgres initialises a throwaway cluster in a temporary directory and
+// starts it on a free port. Durability is switched off; nothing outlives the
+// test binary.
+func startPostgres() (string, func(), error) {
+	initdb, err := findPostgresTool("initdb")
+	if err != nil {
+		return "", nil, err
+	}
+	pgCtl, err := findPostgresTool("pg_ctl")
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "testutil-postgres-")
+	if err != nil {
+		return "", nil, err
+	}
+	data := filepath.Join(dir, "data")
+
+	out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync").CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("initdb: %w: %s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -c fsync=off -c synchronous_commit=off -c full_page_writes=off", port, dir)
+	out, err = exec.Command(pgCtl, "-D", data, "-l", filepath.Join(dir, "postgres.log"), "-w", "-o", opts, "start").CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("pg_ctl start: %w: %s", err, out)
+	}
+
+	stop := func() {
+		exec.Command(pgCtl, "-D", data, "-m", "immediate", "stop").Run()
+		os.RemoveAll(dir)
+	}
+	return fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port), stop, nil
+}
+
+// findPostgresTool looks for a postgres program in TEST_PG_BIN, then PATH.
+func findPostgresTool(name string) (string, error) {
+	if dir := os.Getenv("TEST_PG_BIN"); dir != "" {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("%w: %v", errNoPostgres, err)
+		}
+		return path, nil
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", errNoPostgres
+	}
+	return path, nil
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,57 @@
+package testutil
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hrutav-modha/social-media-app/server/internal/server"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/redis/go-redis/v9"
+)
+
+// Credentials the S3 stand-in is reached with. It accepts any.
+const (
+	S3AccessKey = "testutil"
+	S3SecretKey = "testutil-secret"
+)
+
+// Redis starts an in-memory Redis for the test and returns a client for it.
+// The server is returned too, so tests can fast-forward TTLs with
+// FastForward or inspect keys directly.
+func Redis(t testing.TB) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return rdb, mr
+}
+
+// S3 starts an in-memory S3 service standing in for MinIO, creates the media
+// bucket and returns a client for it along with its host:port endpoint.
+func S3(t testing.TB) (*minio.Client, string) {
+	t.Helper()
+	srv := httptest.NewServer(gofakes3.New(s3mem.New()).Server())
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := minio.New(u.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(S3AccessKey, S3SecretKey, ""),
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		t.Fatalf("failed to create S3 client: %v", err)
+	}
+	if err := client.MakeBucket(context.Background(), server.MediaBucket, minio.MakeBucketOptions{}); err != nil {
+		t.Fatalf("failed to create bucket: %v", err)
+	}
+	return client, u.Host
+}
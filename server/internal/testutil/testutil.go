@@ -0,0 +1,33 @@
+// Package testutil provides disposable dependencies for integration tests: a
+// Postgres schema per test with the migrations applied, an in-memory Redis,
+// an in-memory S3 standing in for MinIO, and a fully wired API server on top
+// of them.
+//
+// Packages that use Postgres should hand control to Main from TestMain, so a
+// locally started server is shut down when their tests finish:
+//
+//	func TestMain(m *testing.M) { os.Exit(testutil.Main(m)) }
+package testutil
+
+import (
+	"os"
+	"testing"
+)
+
+// Main runs the tests and then releases the shared resources, such as a
+// postgres process started for them. It returns the exit code for os.Exit.
+func Main(m *testing.M) int {
+	code := m.Run()
+	shutdownPostgres()
+	return code
+}
+
+// unavailable skips the test because a dependency is missing, or fails it on
+// CI, where a skipped integration test would go unnoticed.
+func unavailable(t testing.TB, format string, args ...any) {
+	t.Helper()
+	if os.Getenv("CI") != "" {
+		t.Fatalf(format, args...)
+	}
+	t.Skipf(format, args...)
+}
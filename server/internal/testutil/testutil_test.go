@@ -0,0 +1,126 @@
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/auth"
+	"github.com/hrutav-modha/social-media-app/server/internal/server"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) { os.Exit(Main(m)) }
+
+func TestStores(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Redis expires keys on fast-forward", func(t *testing.T) {
+		rdb, mr := Redis(t)
+		require.NoError(t, rdb.Set(ctx, "key", "value", time.Minute).Err())
+
+		mr.FastForward(2 * time.Minute)
+		assert.Zero(t, rdb.Exists(ctx, "key").Val())
+	})
+
+	t.Run("S3 stores objects in the media bucket", func(t *testing.T) {
+		client, _ := S3(t)
+		data := []byte("avatar bytes")
+		_, err := client.PutObject(ctx, server.MediaBucket, "avatars/1.png", bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{})
+		require.NoError(t, err)
+
+		obj, err := client.GetObject(ctx, server.MediaBucket, "avatars/1.png", minio.GetObjectOptions{})
+		require.NoError(t, err)
+		got, err := io.ReadAll(obj)
+		require.NoError(t, err)
+		assert.Equal(t, data, got)
+	})
+
+	t.Run("Config matches the validated defaults", func(t *testing.T) {
+		cfg := Config()
+		cfg.DBURL, cfg.RedisURL = "postgres://test", "redis://test"
+		cfg.MinioEndpoint, cfg.MinioAccessKey, cfg.MinioSecretKey = "s3", S3AccessKey, S3SecretKey
+		cfg.JWTPrivateKey, cfg.JWTPublicKey = "private", "public"
+		assert.NoError(t, cfg.Validate())
+	})
+}
+
+func TestClock(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	rdb, mr := Redis(t)
+	clock.Follow(mr)
+
+	store := auth.NewSessionStore(rdb, clock, rand.Reader)
+	token, err := store.Create(ctx, "user-1")
+	require.NoError(t, err)
+
+	clock.Advance(auth.RefreshTokenTTL - time.Minute)
+	assert.Equal(t, start.Add(auth.RefreshTokenTTL-time.Minute), clock.Now())
+	token, _, err = store.Rotate(ctx, token)
+	require.NoError(t, err)
+
+	clock.Advance(auth.RefreshTokenTTL)
+	_, _, err = store.Rotate(ctx, token)
+	assert.Error(t, err, "Redis expired the session with the clock")
+}
+
+func TestDB(t *testing.T) {
+	ctx := context.Background()
+	first, second := DB(t), DB(t)
+
+	_, err := first.Exec(ctx, `INSERT INTO users (username, email, password_hash, display_name) VALUES ('alice', 'alice@example.com', 'x', 'Alice')`)
+	require.NoError(t, err)
+
+	var n int
+	require.NoError(t, second.QueryRow(ctx, `SELECT count(*) FROM users`).Scan(&n))
+	assert.Zero(t, n, "schemas must be isolated")
+}
+
+func TestServer(t *testing.T) {
+	s := NewServer(t)
+	alice := s.RegisterUser(t, "alice")
+
+	t.Run("Readiness sees every dependency", func(t *testing.T) {
+		resp := s.Do(t, http.MethodGet, "/readyz", nil, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Registered users get working tokens", func(t *testing.T) {
+		userID, err := s.App.Tokens.ValidateAccessToken(alice.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, alice.ID, userID)
+
+		req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/auth/refresh", nil)
+		require.NoError(t, err)
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: alice.RefreshToken})
+		resp, err := s.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Protected routes need a token", func(t *testing.T) {
+		resp := s.Do(t, http.MethodPost, "/api/v1/auth/logout", nil, nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp = s.Do(t, http.MethodPost, "/api/v1/auth/logout", nil, alice)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("Tokens expire as the clock advances", func(t *testing.T) {
+		bob := s.RegisterUser(t, "bob")
+		s.Clock.Advance(auth.AccessTokenTTL + time.Second)
+
+		resp := s.Do(t, http.MethodPost, "/api/v1/auth/logout", nil, bob)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
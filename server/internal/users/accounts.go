@@ -0,0 +1,252 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/auth"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/httpx"
+	"github.com/hrutav-modha/social-media-app/server/internal/logging"
+	"github.com/jackc/pgx/v5"
+)
+
+// DeletionGracePeriod is how long a deleted account can still be restored
+// before it is purged for good.
+const DeletionGracePeriod = 30 * 24 * time.Hour
+
+const purgeBatch = 100
+
+// errDeletionDue means an account can no longer be restored because its
+// deletion date has passed.
+var errDeletionDue = errors.New("account deletion is due")
+
+// Accounts deactivates, deletes and restores accounts, and purges the ones
+// whose deletion is due.
+type Accounts struct {
+	store    db.Store
+	sessions *auth.SessionStore
+	media    Media
+	clock    auth.Clock
+}
+
+// NewAccounts returns an Accounts that signs users out through sessions
+// and removes their uploads from media when purging them.
+func NewAccounts(store db.Store, sessions *auth.SessionStore, media Media, clock auth.Clock) *Accounts {
+	return &Accounts{store: store, sessions: sessions, media: media, clock: clock}
+}
+
+// Active reports whether userID, as carried by an access token, names an
+// account that exists and is not deactivated. It backs
+// middleware.RequireActive.
+func (a *Accounts) Active(ctx context.Context, userID string) (bool, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return false, nil
+	}
+	return a.store.IsAccountActive(ctx, id)
+}
+
+// Deactivate hides userID's account and everything in it until it is
+// restored, and signs them out everywhere.
+func (a *Accounts) Deactivate(ctx context.Context, userID uuid.UUID) error {
+	if err := a.store.DeactivateUser(ctx, db.DeactivateUserParams{Now: a.clock.Now(), ID: userID}); err != nil {
+		return err
+	}
+	_, err := a.sessions.RevokeUser(ctx, userID.String())
+	return err
+}
+
+// ScheduleDeletion deactivates userID's account, signs them out everywhere
+// and schedules the account to be purged after DeletionGracePeriod. It
+// returns when that will be.
+func (a *Accounts) ScheduleDeletion(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	now := a.clock.Now()
+	at, err := a.store.ScheduleUserDeletion(ctx, db.ScheduleUserDeletionParams{
+		Now:      now,
+		DeleteAt: now.Add(DeletionGracePeriod),
+		ID:       userID,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if _, err := a.sessions.RevokeUser(ctx, userID.String()); err != nil {
+		return time.Time{}, err
+	}
+	return *at, nil
+}
+
+// Restore reactivates userID's account and cancels its deletion, unless
+// the deletion is already due.
+func (a *Accounts) Restore(ctx context.Context, userID uuid.UUID) error {
+	n, err := a.store.RestoreUser(ctx, db.RestoreUserParams{ID: userID, Now: a.clock.Now()})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errDeletionDue
+	}
+	return nil
+}
+
+// PurgeDue purges every account whose deletion is due, returning how many
+// were. An account that fails is skipped and left for the next run, so a
+// few that keep failing can't hold up the ones due after them.
+func (a *Accounts) PurgeDue(ctx context.Context) (int, error) {
+	purged := 0
+	var errs []error
+	params := db.ListUsersDueForDeletionParams{Now: a.clock.Now(), PageSize: purgeBatch}
+	for {
+		rows, err := a.store.ListUsersDueForDeletion(ctx, params)
+		if err != nil {
+			return purged, err
+		}
+		for _, row := range rows {
+			deleted, err := a.purge(ctx, row.ID)
+			if deleted {
+				purged++
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return purged, ctx.Err()
+				}
+				errs = append(errs, fmt.Errorf("user %s: %w", row.ID, err))
+			}
+		}
+		if len(rows) < purgeBatch {
+			return purged, errors.Join(errs...)
+		}
+		last := rows[len(rows)-1]
+		params.AfterScheduledAt, params.AfterID = last.DeletionScheduledAt, &last.ID
+	}
+}
+
+// purge deletes userID's account if it is still due, which takes their
+// posts, follows, likes and notifications with it; their comments with
+// replies stay behind as tombstones. Only once that has committed do their
+// uploads and sessions go, so an account restored in the meantime keeps
+// both; a failure there is reported but not retried. It reports whether the
+// account was deleted.
+func (a *Accounts) purge(ctx context.Context, userID uuid.UUID) (bool, error) {
+	now := a.clock.Now()
+	deleted := false
+	err := a.store.InTx(ctx, func(q db.Querier) error {
+		// The account may have been restored, or purged by another server,
+		// since it was listed.
+		scheduledAt, err := q.LockUserForDeletion(ctx, userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if scheduledAt == nil || scheduledAt.After(now) {
+			return nil
+		}
+		if err := q.PurgeUserComments(ctx, db.PurgeUserCommentsParams{UserID: userID, Now: now}); err != nil {
+			return err
+		}
+		n, err := q.DeleteUser(ctx, userID)
+		deleted = n > 0
+		return err
+	})
+	if err != nil || !deleted {
+		return false, err
+	}
+
+	if a.media.Client != nil {
+		if err := a.media.removePrefix(ctx, fmt.Sprintf("avatars/%s/", userID)); err != nil {
+			return true, err
+		}
+	}
+	if _, err := a.sessions.RevokeUser(ctx, userID.String()); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// Run calls PurgeDue every interval until ctx is done.
+func (a *Accounts) Run(ctx context.Context, interval time.Duration) {
+	log := logging.FromContext(ctx).With(logging.ModuleKey, "users")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n, err := a.PurgeDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error("failed to purge deleted accounts", slog.Int("purged", n), slog.Any("error", err))
+			continue
+		}
+		if n > 0 {
+			log.Info("purged deleted accounts", slog.Int("purged", n))
+		}
+	}
+}
+
+// DeletionResponse is the body of DELETE /api/v1/users/me.
+type DeletionResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+// DeactivateAccount handles POST /api/v1/users/me/deactivate. The account
+// disappears for everyone else and all sessions end; signing in again and
+// calling restore brings it back.
+func (h *Handler) DeactivateAccount(w http.ResponseWriter, r *http.Request) {
+	viewer, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+	if err := h.accounts.Deactivate(r.Context(), viewer); err != nil {
+		internalError(w, r, "failed to deactivate account", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteAccount handles DELETE /api/v1/users/me. The account is
+// deactivated at once and purged after DeletionGracePeriod unless restored
+// before then. Asking again keeps the original date.
+func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	viewer, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+	at, err := h.accounts.ScheduleDeletion(r.Context(), viewer)
+	if err != nil {
+		internalError(w, r, "failed to schedule account deletion", err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusAccepted, DeletionResponse{DeletionScheduledAt: at})
+}
+
+// RestoreAccount handles POST /api/v1/users/me/restore, undoing a
+// deactivation or a deletion still within its grace period. Restoring an
+// active account changes nothing.
+func (h *Handler) RestoreAccount(w http.ResponseWriter, r *http.Request) {
+	viewer, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+	err = h.accounts.Restore(r.Context(), viewer)
+	if errors.Is(err, errDeletionDue) {
+		httpx.WriteError(w, http.StatusGone, "account_deleted", "the account is past its deletion date")
+		return
+	}
+	if err != nil {
+		internalError(w, r, "failed to restore account", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,350 @@
+package users_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/auth"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/middleware"
+	"github.com/hrutav-modha/social-media-app/server/internal/server"
+	"github.com/hrutav-modha/social-media-app/server/internal/testutil"
+	"github.com/hrutav-modha/social-media-app/server/internal/users"
+	"github.com/jackc/pgx/v5"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// accountStore keeps account states in memory and records whose comments
+// were purged. Purging the accounts in broken fails, and onLock runs just
+// before an account is locked for purging.
+type accountStore struct {
+	db.Store
+	users          map[uuid.UUID]*db.User
+	purgedComments []uuid.UUID
+	broken         map[uuid.UUID]bool
+	onLock         func(id uuid.UUID)
+}
+
+func (s *accountStore) InTx(_ context.Context, fn func(db.Querier) error) error {
+	return fn(s)
+}
+
+func (s *accountStore) GetUserByID(_ context.Context, id uuid.UUID) (db.User, error) {
+	u, ok := s.users[id]
+	if !ok {
+		return db.User{}, pgx.ErrNoRows
+	}
+	return *u, nil
+}
+
+func (s *accountStore) DeactivateUser(_ context.Context, arg db.DeactivateUserParams) error {
+	if u := s.users[arg.ID]; u.DeactivatedAt == nil {
+		u.DeactivatedAt = &arg.Now
+	}
+	return nil
+}
+
+func (s *accountStore) ScheduleUserDeletion(_ context.Context, arg db.ScheduleUserDeletionParams) (*time.Time, error) {
+	u := s.users[arg.ID]
+	if u.DeactivatedAt == nil {
+		u.DeactivatedAt = &arg.Now
+	}
+	if u.DeletionScheduledAt == nil {
+		u.DeletionScheduledAt = &arg.DeleteAt
+	}
+	return u.DeletionScheduledAt, nil
+}
+
+func (s *accountStore) RestoreUser(_ context.Context, arg db.RestoreUserParams) (int64, error) {
+	u, ok := s.users[arg.ID]
+	if !ok || u.DeletionScheduledAt != nil && !u.DeletionScheduledAt.After(arg.Now) {
+		return 0, nil
+	}
+	u.DeactivatedAt, u.DeletionScheduledAt = nil, nil
+	return 1, nil
+}
+
+func (s *accountStore) ListUsersDueForDeletion(_ context.Context, arg db.ListUsersDueForDeletionParams) ([]db.ListUsersDueForDeletionRow, error) {
+	var rows []db.ListUsersDueForDeletionRow
+	for id, u := range s.users {
+		if u.DeletionScheduledAt != nil && !u.DeletionScheduledAt.After(arg.Now) {
+			rows = append(rows, db.ListUsersDueForDeletionRow{ID: id, DeletionScheduledAt: u.DeletionScheduledAt})
+		}
+	}
+	key := func(r db.ListUsersDueForDeletionRow) string {
+		return r.DeletionScheduledAt.UTC().Format(time.RFC3339Nano) + r.ID.String()
+	}
+	slices.SortFunc(rows, func(a, b db.ListUsersDueForDeletionRow) int { return strings.Compare(key(a), key(b)) })
+	if arg.AfterScheduledAt != nil {
+		after := key(db.ListUsersDueForDeletionRow{ID: *arg.AfterID, DeletionScheduledAt: arg.AfterScheduledAt})
+		rows = slices.DeleteFunc(rows, func(r db.ListUsersDueForDeletionRow) bool { return key(r) <= after })
+	}
+	return rows[:min(len(rows), int(arg.PageSize))], nil
+}
+
+func (s *accountStore) LockUserForDeletion(_ context.Context, id uuid.UUID) (*time.Time, error) {
+	if s.onLock != nil {
+		s.onLock(id)
+	}
+	u, ok := s.users[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return u.DeletionScheduledAt, nil
+}
+
+func (s *accountStore) PurgeUserComments(_ context.Context, arg db.PurgeUserCommentsParams) error {
+	if s.broken[arg.UserID] {
+		return errors.New("purge failed")
+	}
+	s.purgedComments = append(s.purgedComments, arg.UserID)
+	return nil
+}
+
+func (s *accountStore) DeleteUser(_ context.Context, id uuid.UUID) (int64, error) {
+	delete(s.users, id)
+	return 1, nil
+}
+
+func TestAccounts(t *testing.T) {
+	ctx := context.Background()
+	alice, bob := uuid.New(), uuid.New()
+	store := &accountStore{users: map[uuid.UUID]*db.User{
+		alice: {ID: alice, Username: "alice"},
+		bob:   {ID: bob, Username: "bob"},
+	}}
+
+	rdb, mr := testutil.Redis(t)
+	clock := testutil.NewClock(testutil.Epoch)
+	clock.Follow(mr)
+	sessions := auth.NewSessionStore(rdb, clock, rand.Reader)
+	s3, endpoint := testutil.S3(t)
+	media := users.Media{Client: s3, Bucket: server.MediaBucket, BaseURL: "http://" + endpoint + "/media"}
+	accounts := users.NewAccounts(store, sessions, media, clock)
+	h := users.NewHandler(store, clock, media, nil, accounts)
+
+	_, tokens := newRouter(t, h)
+	r := chi.NewRouter()
+	r.Use(middleware.Auth(tokens))
+	r.Delete("/users/me", h.DeleteAccount)
+	r.Post("/users/me/deactivate", h.DeactivateAccount)
+	r.Post("/users/me/restore", h.RestoreAccount)
+	do := func(as uuid.UUID, method, path string) *httptest.ResponseRecorder {
+		token, err := tokens.GenerateAccessToken(as.String())
+		require.NoError(t, err)
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Deactivating signs the user out everywhere", func(t *testing.T) {
+		for range 2 {
+			_, err := sessions.Create(ctx, alice.String())
+			require.NoError(t, err)
+		}
+		assert.Equal(t, http.StatusNoContent, do(alice, http.MethodPost, "/users/me/deactivate").Code)
+		assert.Equal(t, testutil.Epoch, *store.users[alice].DeactivatedAt)
+		assert.False(t, mr.Exists(auth.UserSessionsPrefix+alice.String()))
+
+		assert.Equal(t, http.StatusNoContent, do(alice, http.MethodPost, "/users/me/restore").Code)
+		assert.Nil(t, store.users[alice].DeactivatedAt)
+	})
+
+	t.Run("Deletion keeps its first date", func(t *testing.T) {
+		deletionDate := func() time.Time {
+			w := do(alice, http.MethodDelete, "/users/me")
+			require.Equal(t, http.StatusAccepted, w.Code)
+			var resp users.DeletionResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			return resp.DeletionScheduledAt
+		}
+		due := testutil.Epoch.Add(users.DeletionGracePeriod)
+		assert.Equal(t, due, deletionDate().UTC())
+		clock.Advance(24 * time.Hour)
+		assert.Equal(t, due, deletionDate().UTC())
+		require.NotNil(t, store.users[alice].DeactivatedAt)
+
+		assert.Equal(t, http.StatusNoContent, do(alice, http.MethodPost, "/users/me/restore").Code)
+		assert.Nil(t, store.users[alice].DeletionScheduledAt)
+	})
+
+	t.Run("Accounts are purged with their uploads once due", func(t *testing.T) {
+		for _, key := range []string{
+			"avatars/" + bob.String() + "/avatar-1-400.jpg",
+			"avatars/" + bob.String() + "/banner-2-1500.jpg",
+			"avatars/" + alice.String() + "/avatar-3-400.jpg",
+		} {
+			_, err := s3.PutObject(ctx, server.MediaBucket, key, bytes.NewReader([]byte("x")), 1, minio.PutObjectOptions{})
+			require.NoError(t, err)
+		}
+		_, err := accounts.ScheduleDeletion(ctx, bob)
+		require.NoError(t, err)
+
+		clock.Advance(users.DeletionGracePeriod - time.Second)
+		n, err := accounts.PurgeDue(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		clock.Advance(time.Second)
+		assert.Equal(t, http.StatusGone, do(bob, http.MethodPost, "/users/me/restore").Code, "too late to restore")
+		n, err = accounts.PurgeDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.NotContains(t, store.users, bob)
+		assert.Equal(t, []uuid.UUID{bob}, store.purgedComments)
+
+		var left []string
+		for obj := range s3.ListObjects(ctx, server.MediaBucket, minio.ListObjectsOptions{Prefix: "avatars/", Recursive: true}) {
+			require.NoError(t, obj.Err)
+			left = append(left, obj.Key)
+		}
+		assert.Equal(t, []string{"avatars/" + alice.String() + "/avatar-3-400.jpg"}, left)
+		assert.Equal(t, http.StatusGone, do(bob, http.MethodPost, "/users/me/restore").Code)
+	})
+
+	t.Run("Accounts restored mid-run keep their uploads and sessions", func(t *testing.T) {
+		dave := uuid.New()
+		store.users[dave] = &db.User{ID: dave, Username: "dave"}
+		key := "avatars/" + dave.String() + "/avatar-4-400.jpg"
+		_, err := s3.PutObject(ctx, server.MediaBucket, key, bytes.NewReader([]byte("x")), 1, minio.PutObjectOptions{})
+		require.NoError(t, err)
+		_, err = accounts.ScheduleDeletion(ctx, dave)
+		require.NoError(t, err)
+
+		clock.Advance(users.DeletionGracePeriod)
+		_, err = sessions.Create(ctx, dave.String())
+		require.NoError(t, err)
+		// dave restores between being listed and being locked.
+		store.onLock = func(id uuid.UUID) { store.users[id].DeletionScheduledAt = nil }
+		defer func() { store.onLock = nil }()
+		n, err := accounts.PurgeDue(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n, "only deleted accounts count")
+		assert.Contains(t, store.users, dave)
+
+		_, err = s3.StatObject(ctx, server.MediaBucket, key, minio.StatObjectOptions{})
+		assert.NoError(t, err)
+		assert.True(t, mr.Exists(auth.UserSessionsPrefix+dave.String()))
+		delete(store.users, dave)
+	})
+
+	t.Run("Accounts that keep failing don't hold up the rest", func(t *testing.T) {
+		// More than two batches' worth of failures, all due before carol.
+		store.broken = map[uuid.UUID]bool{}
+		for range 250 {
+			id := uuid.New()
+			store.users[id] = &db.User{ID: id}
+			store.broken[id] = true
+			_, err := accounts.ScheduleDeletion(ctx, id)
+			require.NoError(t, err)
+		}
+		clock.Advance(time.Second)
+		carol := uuid.New()
+		store.users[carol] = &db.User{ID: carol, Username: "carol"}
+		_, err := accounts.ScheduleDeletion(ctx, carol)
+		require.NoError(t, err)
+
+		clock.Advance(users.DeletionGracePeriod)
+		store.purgedComments = nil
+		n, err := accounts.PurgeDue(ctx)
+		assert.ErrorContains(t, err, "purge failed")
+		assert.Equal(t, 1, n)
+		assert.Equal(t, []uuid.UUID{carol}, store.purgedComments)
+		assert.Len(t, store.users, 251, "alice and the broken accounts are left")
+	})
+}
+
+func TestAccountsAPI(t *testing.T) {
+	s := testutil.NewServer(t)
+	ctx := context.Background()
+	alice := s.RegisterUser(t, "alice")
+	bob := s.RegisterUser(t, "bob")
+	carol := s.RegisterUser(t, "carol")
+	aliceID, bobID, carolID := uuid.MustParse(alice.ID), uuid.MustParse(bob.ID), uuid.MustParse(carol.ID)
+
+	require.Equal(t, http.StatusNoContent, s.Do(t, http.MethodPost, "/api/v1/users/alice/follow", nil, bob).StatusCode)
+	post, err := s.App.Store.CreatePost(ctx, db.CreatePostParams{UserID: aliceID, Content: "hello", MediaUrls: []string{}})
+	require.NoError(t, err)
+	// bob starts a thread carol replies to, and leaves a comment no one answers.
+	thread, err := s.App.Store.CreateComment(ctx, db.CreateCommentParams{PostID: post.ID, UserID: &bobID, Content: "first"})
+	require.NoError(t, err)
+	_, err = s.App.Store.CreateComment(ctx, db.CreateCommentParams{PostID: post.ID, UserID: &carolID, ParentID: &thread.ID, Content: "reply"})
+	require.NoError(t, err)
+	_, err = s.App.Store.CreateComment(ctx, db.CreateCommentParams{PostID: post.ID, UserID: &bobID, Content: "alone"})
+	require.NoError(t, err)
+
+	comments := func() []db.ListPostCommentsRow {
+		t.Helper()
+		rows, err := s.App.Store.ListPostComments(ctx, db.ListPostCommentsParams{PostID: post.ID, ViewerID: aliceID, PageSize: 10})
+		require.NoError(t, err)
+		return rows
+	}
+	status := func(method, path string, as *testutil.User) int {
+		return s.Do(t, method, path, nil, as).StatusCode
+	}
+
+	t.Run("Deactivated accounts disappear until restored", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, status(http.MethodPost, "/api/v1/users/me/deactivate", bob))
+		assert.Equal(t, http.StatusNotFound, status(http.MethodGet, "/api/v1/users/bob", carol))
+		assert.Equal(t, http.StatusNotFound, status(http.MethodPost, "/api/v1/users/bob/follow", carol))
+
+		resp := s.Do(t, http.MethodGet, "/api/v1/users/alice/followers", nil, carol)
+		var followers users.UserPage
+		testutil.DecodeJSON(t, resp, &followers)
+		assert.Empty(t, followers.Users)
+
+		resp = s.Do(t, http.MethodGet, "/api/v1/users/search?"+url.Values{"q": {"bob"}}.Encode(), nil, carol)
+		var found users.SearchPage
+		testutil.DecodeJSON(t, resp, &found)
+		assert.Empty(t, found.Users)
+
+		rows := comments()
+		require.Len(t, rows, 2, "the unanswered comment is hidden")
+		assert.Nil(t, rows[0].UserID)
+		assert.Empty(t, rows[0].Content)
+
+		assert.Equal(t, http.StatusForbidden, status(http.MethodPost, "/api/v1/users/carol/follow", bob), "tokens from before can't write")
+
+		require.Equal(t, http.StatusNoContent, status(http.MethodPost, "/api/v1/users/me/restore", bob))
+		assert.Equal(t, http.StatusOK, status(http.MethodGet, "/api/v1/users/bob", carol))
+		assert.Len(t, comments(), 3)
+	})
+
+	t.Run("Purged accounts leave tombstones in threads", func(t *testing.T) {
+		require.Equal(t, http.StatusAccepted, status(http.MethodDelete, "/api/v1/users/me", bob))
+		s.Clock.Advance(users.DeletionGracePeriod)
+		n, err := s.App.Accounts.PurgeDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		_, err = s.App.Store.GetUserByID(ctx, bobID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		profile, err := s.App.Store.GetUserProfile(ctx, db.GetUserProfileParams{Username: "alice"})
+		require.NoError(t, err)
+		assert.Zero(t, profile.FollowerCount)
+
+		rows := comments()
+		require.Len(t, rows, 2)
+		assert.Equal(t, thread.ID, rows[0].ID)
+		assert.Nil(t, rows[0].UserID)
+		assert.Empty(t, rows[0].Content)
+		assert.Equal(t, thread.ID, *rows[1].ParentID)
+		assert.Equal(t, "reply", rows[1].Content)
+	})
+}
@@ -0,0 +1,109 @@
+package users
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/httpx"
+)
+
+func writeBlocked(w http.ResponseWriter) {
+	httpx.WriteError(w, http.StatusForbidden, "blocked", "you can't interact with this user")
+}
+
+// Block handles POST /api/v1/users/{username}/block. Blocking removes any
+// follows and follow requests between the two users, both ways; the block
+// itself keeps new ones and everything else from happening. Blocking twice
+// is a no-op.
+func (h *Handler) Block(w http.ResponseWriter, r *http.Request) {
+	h.setRelation(w, r, "block", func(ctx context.Context, q db.Querier, viewer, target uuid.UUID) error {
+		if _, err := q.CreateBlock(ctx, db.CreateBlockParams{BlockerID: viewer, BlockedID: target}); err != nil {
+			return err
+		}
+		if err := unfollow(ctx, q, viewer, target); err != nil {
+			return err
+		}
+		return unfollow(ctx, q, target, viewer)
+	})
+}
+
+// Unblock handles DELETE /api/v1/users/{username}/block. Follows removed by
+// the block are not restored.
+func (h *Handler) Unblock(w http.ResponseWriter, r *http.Request) {
+	h.setRelation(w, r, "block", func(ctx context.Context, q db.Querier, viewer, target uuid.UUID) error {
+		_, err := q.DeleteBlock(ctx, db.DeleteBlockParams{BlockerID: viewer, BlockedID: target})
+		return err
+	})
+}
+
+// muteRequest is the optional body of a mute. Without ExpiresIn, in
+// seconds, the mute lasts until it is undone.
+type muteRequest struct {
+	ExpiresIn *int64 `json:"expires_in"`
+}
+
+// MaxMuteDuration bounds timed mutes; longer ones should be indefinite.
+const MaxMuteDuration = 365 * 24 * time.Hour
+
+// Mute handles POST /api/v1/users/{username}/mute. Muting hides the user
+// from the viewer's feed and notifications, and nothing else; they are not
+// told. Muting again replaces the expiry.
+func (h *Handler) Mute(w http.ResponseWriter, r *http.Request) {
+	var req muteRequest
+	if !httpx.DecodeOptionalJSON(w, r, &req) {
+		return
+	}
+	var expiresAt *time.Time
+	if req.ExpiresIn != nil {
+		// Check the range before converting, which could overflow.
+		if *req.ExpiresIn <= 0 || *req.ExpiresIn > int64(MaxMuteDuration/time.Second) {
+			httpx.WriteError(w, http.StatusBadRequest, "invalid_mute", "expires_in must be a positive number of seconds, at most a year")
+			return
+		}
+		t := h.clock.Now().Add(time.Duration(*req.ExpiresIn) * time.Second)
+		expiresAt = &t
+	}
+
+	h.setRelation(w, r, "mute", func(ctx context.Context, q db.Querier, viewer, target uuid.UUID) error {
+		return q.UpsertMute(ctx, db.UpsertMuteParams{MuterID: viewer, MutedID: target, ExpiresAt: expiresAt})
+	})
+}
+
+// Unmute handles DELETE /api/v1/users/{username}/mute.
+func (h *Handler) Unmute(w http.ResponseWriter, r *http.Request) {
+	h.setRelation(w, r, "mute", func(ctx context.Context, q db.Querier, viewer, target uuid.UUID) error {
+		_, err := q.DeleteMute(ctx, db.DeleteMuteParams{MuterID: viewer, MutedID: target})
+		return err
+	})
+}
+
+// setRelation runs change in a transaction between the viewer and
+// {username}, answering 204. kind names the relation in errors.
+func (h *Handler) setRelation(w http.ResponseWriter, r *http.Request, kind string, change func(ctx context.Context, q db.Querier, viewer, target uuid.UUID) error) {
+	ctx := r.Context()
+	viewer, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+	target, ok := h.lookupUser(w, r)
+	if !ok {
+		return
+	}
+	if target.ID == viewer {
+		httpx.WriteError(w, http.StatusBadRequest, "cannot_"+kind+"_self", "you cannot "+kind+" yourself")
+		return
+	}
+
+	err = h.store.InTx(ctx, func(q db.Querier) error {
+		return change(ctx, q, viewer, target.ID)
+	})
+	if err != nil {
+		internalError(w, r, "failed to update "+kind, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
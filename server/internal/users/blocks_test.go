@@ -0,0 +1,156 @@
+package users_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/testutil"
+	"github.com/hrutav-modha/social-media-app/server/internal/users"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlock(t *testing.T) {
+	alice := db.User{ID: uuid.New(), Username: "alice"}
+	bob := db.User{ID: uuid.New(), Username: "bob"}
+	carol := db.User{ID: uuid.New(), Username: "carol", IsPrivate: true}
+	store := newFollowStore(alice, bob, carol)
+	do := relationRouter(t, store)
+
+	t.Run("Blocking severs follows both ways", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, do(alice, http.MethodPost, "/users/bob/follow"))
+		require.Equal(t, http.StatusNoContent, do(bob, http.MethodPost, "/users/alice/follow"))
+
+		assert.Equal(t, http.StatusNoContent, do(alice, http.MethodPost, "/users/bob/block"))
+		assert.Equal(t, http.StatusNoContent, do(alice, http.MethodPost, "/users/bob/block"))
+		assert.Empty(t, store.follows)
+		assert.Empty(t, store.notifications)
+	})
+
+	t.Run("Blocks stop new follows either way", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(bob, http.MethodPost, "/users/alice/follow"))
+		assert.Equal(t, http.StatusForbidden, do(alice, http.MethodPost, "/users/bob/follow"))
+
+		assert.Equal(t, http.StatusNoContent, do(alice, http.MethodDelete, "/users/bob/block"))
+		assert.Equal(t, http.StatusNoContent, do(bob, http.MethodPost, "/users/alice/follow"))
+	})
+
+	t.Run("Blocking cancels follow requests", func(t *testing.T) {
+		require.Equal(t, http.StatusAccepted, do(bob, http.MethodPost, "/users/carol/follow"))
+		assert.Equal(t, http.StatusNoContent, do(carol, http.MethodPost, "/users/bob/block"))
+		assert.Empty(t, store.requests)
+		assert.Equal(t, http.StatusForbidden, do(bob, http.MethodPost, "/users/carol/follow"))
+	})
+
+	t.Run("Mutes are indefinite unless they expire", func(t *testing.T) {
+		edge := [2]uuid.UUID{alice.ID, bob.ID}
+		assert.Equal(t, http.StatusNoContent, do(alice, http.MethodPost, "/users/bob/mute"))
+		require.Contains(t, store.mutes, edge)
+		assert.Nil(t, store.mutes[edge])
+
+		assert.Equal(t, http.StatusNoContent, do(alice, http.MethodPost, "/users/bob/mute", `{"expires_in": 3600}`))
+		require.NotNil(t, store.mutes[edge])
+		assert.Equal(t, testutil.Epoch.Add(time.Hour), *store.mutes[edge])
+
+		assert.Equal(t, http.StatusBadRequest, do(alice, http.MethodPost, "/users/bob/mute", `{"expires_in": 0}`))
+		assert.Equal(t, http.StatusBadRequest, do(alice, http.MethodPost, "/users/bob/mute", `{"expires_in": 9223372036854775807}`), "no overflow past the limit")
+		assert.Equal(t, http.StatusBadRequest, do(alice, http.MethodPost, "/users/alice/mute"))
+
+		assert.Equal(t, http.StatusNoContent, do(alice, http.MethodDelete, "/users/bob/mute"))
+		assert.NotContains(t, store.mutes, edge)
+		assert.True(t, store.follows[[2]uuid.UUID{bob.ID, alice.ID}], "muting leaves follows alone")
+	})
+}
+
+func TestBlockAPI(t *testing.T) {
+	s := testutil.NewServer(t)
+	ctx := context.Background()
+	alice := s.RegisterUser(t, "alice")
+	bob := s.RegisterUser(t, "bob")
+	carol := s.RegisterUser(t, "carol")
+	aliceID, bobID, carolID := uuid.MustParse(alice.ID), uuid.MustParse(bob.ID), uuid.MustParse(carol.ID)
+
+	post, err := s.App.Store.CreatePost(ctx, db.CreatePostParams{UserID: aliceID, Content: "hello", MediaUrls: []string{}})
+	require.NoError(t, err)
+	for _, u := range []*testutil.User{bob, carol} {
+		require.Equal(t, http.StatusNoContent, s.Do(t, http.MethodPost, "/api/v1/users/alice/follow", nil, u).StatusCode)
+	}
+
+	t.Run("Blocked users can't see or touch content", func(t *testing.T) {
+		_, err := s.App.Store.CreateComment(ctx, db.CreateCommentParams{PostID: post.ID, UserID: &bobID, Content: "before"})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNoContent, s.Do(t, http.MethodPost, "/api/v1/users/bob/block", nil, alice).StatusCode)
+
+		assert.Equal(t, http.StatusNotFound, s.Do(t, http.MethodGet, "/api/v1/users/alice", nil, bob).StatusCode)
+		for _, list := range []string{"followers", "following", "mutuals"} {
+			resp := s.Do(t, http.MethodGet, "/api/v1/users/alice/"+list, nil, bob)
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode, list)
+		}
+		resp := s.Do(t, http.MethodGet, "/api/v1/users/bob", nil, alice)
+		var blocked users.Profile
+		testutil.DecodeJSON(t, resp, &blocked)
+		require.NotNil(t, blocked.IsBlocked)
+		assert.True(t, *blocked.IsBlocked, "the blocker can still find them to unblock")
+		profile, err := s.App.Store.GetUserProfile(ctx, db.GetUserProfileParams{Username: "alice", ViewerID: carolID})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, profile.FollowerCount, "the block removed bob's follow")
+
+		// carol follows alice, but bob can't see that in carol's list.
+		resp = s.Do(t, http.MethodGet, "/api/v1/users/carol/following", nil, bob)
+		var following users.UserPage
+		testutil.DecodeJSON(t, resp, &following)
+		assert.Empty(t, following.Users)
+
+		_, err = s.App.Store.GetPost(ctx, db.GetPostParams{ID: post.ID, ViewerID: bobID})
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		comments, err := s.App.Store.ListPostComments(ctx, db.ListPostCommentsParams{PostID: post.ID, ViewerID: aliceID, PageSize: 10})
+		require.NoError(t, err)
+		assert.Empty(t, comments, "the blocker no longer sees the blocked user's comments")
+
+		_, err = s.App.Store.CreateLike(ctx, db.CreateLikeParams{UserID: bobID, PostID: post.ID})
+		assert.True(t, db.IsBlocked(err), "likes: %v", err)
+		_, err = s.App.Store.CreateComment(ctx, db.CreateCommentParams{PostID: post.ID, UserID: &bobID, Content: "after"})
+		assert.True(t, db.IsBlocked(err), "comments: %v", err)
+		_, err = s.App.Store.CreateNotification(ctx, db.CreateNotificationParams{
+			RecipientID: aliceID, ActorID: bobID, Type: "mention", EntityID: post.ID, EntityType: "post",
+		})
+		assert.True(t, db.IsBlocked(err), "mentions: %v", err)
+
+		resp = s.Do(t, http.MethodPost, "/api/v1/users/alice/follow", nil, bob)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Mutes filter the feed and notifications until they expire", func(t *testing.T) {
+		unread := func() int64 {
+			n, err := s.App.Store.CountUnreadNotifications(ctx, db.CountUnreadNotificationsParams{RecipientID: aliceID, Now: s.Clock.Now()})
+			require.NoError(t, err)
+			return n
+		}
+		feed := func() int {
+			posts, err := s.App.Store.ListFeed(ctx, db.ListFeedParams{ViewerID: carolID, Now: s.Clock.Now(), PageSize: 10})
+			require.NoError(t, err)
+			return len(posts)
+		}
+		require.EqualValues(t, 1, unread(), "carol's follow")
+		require.Equal(t, 1, feed())
+
+		require.Equal(t, http.StatusNoContent, s.Do(t, http.MethodPost, "/api/v1/users/carol/mute", map[string]int{"expires_in": 3600}, alice).StatusCode)
+		require.Equal(t, http.StatusNoContent, s.Do(t, http.MethodPost, "/api/v1/users/alice/mute", nil, carol).StatusCode)
+		assert.Zero(t, unread())
+		assert.Zero(t, feed())
+
+		resp := s.Do(t, http.MethodGet, "/api/v1/users/carol", nil, alice)
+		var profile users.Profile
+		testutil.DecodeJSON(t, resp, &profile)
+		assert.True(t, *profile.IsMuted)
+
+		s.Clock.Advance(time.Hour + time.Second)
+		assert.EqualValues(t, 1, unread(), "timed mutes lapse")
+		assert.Zero(t, feed(), "indefinite mutes don't")
+	})
+}
@@ -0,0 +1,357 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/httpx"
+	"github.com/hrutav-modha/social-media-app/server/internal/logging"
+	"github.com/jackc/pgx/v5"
+)
+
+// Profile field limits, in characters.
+const (
+	MaxDisplayNameLength = 50
+	MaxBioLength         = 160
+	MaxLocationLength    = 30
+	MaxPronounsLength    = 30
+	MaxLinks             = 5
+	MaxLinkLength        = 200
+)
+
+// updateProfileRequest is a partial update: omitted fields are left alone
+// and an empty string clears an optional one.
+type updateProfileRequest struct {
+	DisplayName *string   `json:"display_name"`
+	Bio         *string   `json:"bio"`
+	Location    *string   `json:"location"`
+	Pronouns    *string   `json:"pronouns"`
+	Links       *[]string `json:"links"`
+	IsPrivate   *bool     `json:"is_private"`
+}
+
+// apply validates req and merges it into the user's current fields.
+func (req *updateProfileRequest) apply(p *db.UpdateUserProfileParams) error {
+	if req.DisplayName != nil {
+		name, err := cleanText("display_name", *req.DisplayName, MaxDisplayNameLength, false)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			return errors.New("display_name must not be empty")
+		}
+		p.DisplayName = name
+	}
+
+	optional := []struct {
+		field     string
+		value     *string
+		dst       **string
+		max       int
+		multiline bool
+	}{
+		{"bio", req.Bio, &p.Bio, MaxBioLength, true},
+		{"location", req.Location, &p.Location, MaxLocationLength, false},
+		{"pronouns", req.Pronouns, &p.Pronouns, MaxPronounsLength, false},
+	}
+	for _, o := range optional {
+		if o.value == nil {
+			continue
+		}
+		s, err := cleanText(o.field, *o.value, o.max, o.multiline)
+		if err != nil {
+			return err
+		}
+		*o.dst = nil
+		if s != "" {
+			*o.dst = &s
+		}
+	}
+
+	if req.Links != nil {
+		links, err := cleanLinks(*req.Links)
+		if err != nil {
+			return err
+		}
+		p.Links = links
+	}
+	return nil
+}
+
+// cleanText trims s and checks its length and characters. Only multiline
+// fields may contain line breaks; other control characters are refused
+// everywhere.
+func cleanText(field, s string, max int, multiline bool) (string, error) {
+	s = strings.TrimSpace(s)
+	if !utf8.ValidString(s) {
+		return "", fmt.Errorf("%s must be valid UTF-8", field)
+	}
+	if n := utf8.RuneCountInString(s); n > max {
+		return "", fmt.Errorf("%s must be at most %d characters", field, max)
+	}
+	for _, r := range s {
+		if r == '\n' && multiline {
+			continue
+		}
+		if unicode.IsControl(r) {
+			return "", fmt.Errorf("%s must not contain control characters", field)
+		}
+	}
+	return s, nil
+}
+
+// cleanLinks checks that links are absolute http(s) URLs and drops
+// duplicates and blanks.
+func cleanLinks(links []string) ([]string, error) {
+	out := []string{}
+	seen := make(map[string]bool)
+	for _, l := range links {
+		l = strings.TrimSpace(l)
+		if l == "" || seen[l] {
+			continue
+		}
+		if len(l) > MaxLinkLength {
+			return nil, fmt.Errorf("links must be at most %d characters", MaxLinkLength)
+		}
+		u, err := url.Parse(l)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+			return nil, fmt.Errorf("link %q must be an http or https URL", l)
+		}
+		seen[l] = true
+		out = append(out, l)
+	}
+	if len(out) > MaxLinks {
+		return nil, fmt.Errorf("at most %d links are allowed", MaxLinks)
+	}
+	return out, nil
+}
+
+// UpdateProfile handles PATCH /api/v1/users/me and returns the updated
+// profile.
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+
+	var req updateProfileRequest
+	if !httpx.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	var user db.User
+	err = h.store.InTx(ctx, func(q db.Querier) error {
+		current, err := q.GetUserByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		params := db.UpdateUserProfileParams{
+			ID:          userID,
+			DisplayName: current.DisplayName,
+			Bio:         current.Bio,
+			Location:    current.Location,
+			Pronouns:    current.Pronouns,
+			Links:       current.Links,
+		}
+		if err := req.apply(&params); err != nil {
+			return &validationError{err}
+		}
+		if user, err = q.UpdateUserProfile(ctx, params); err != nil {
+			return err
+		}
+		if req.IsPrivate != nil {
+			return setPrivacy(ctx, q, userID, *req.IsPrivate)
+		}
+		return nil
+	})
+
+	var invalid *validationError
+	switch {
+	case errors.As(err, &invalid):
+		httpx.WriteError(w, http.StatusBadRequest, "invalid_profile", invalid.Error())
+		return
+	case errors.Is(err, pgx.ErrNoRows):
+		httpx.WriteError(w, http.StatusNotFound, "user_not_found", "your account no longer exists")
+		return
+	case err != nil:
+		internalError(w, r, "failed to update profile", err)
+		return
+	}
+
+	profile, err := h.loadProfile(ctx, user.Username, userID)
+	if err != nil {
+		internalError(w, r, "failed to load profile", err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, profile)
+}
+
+// validationError marks a request the client must fix, as opposed to a
+// failure of the store.
+type validationError struct {
+	err error
+}
+
+func (e *validationError) Error() string { return e.err.Error() }
+
+// imageSlot is a profile image: where its files go and which column points
+// at them.
+type imageSlot struct {
+	name       string
+	renditions []rendition
+	// set stores url as the slot's column and returns the URL it replaced.
+	set func(ctx context.Context, q db.Querier, userID uuid.UUID, url string) (*string, error)
+}
+
+var (
+	avatarSlot = imageSlot{
+		name:       "avatar",
+		renditions: avatarRenditions,
+		set: func(ctx context.Context, q db.Querier, userID uuid.UUID, url string) (*string, error) {
+			return q.UpdateUserAvatar(ctx, db.UpdateUserAvatarParams{ID: userID, AvatarUrl: &url})
+		},
+	}
+	bannerSlot = imageSlot{
+		name:       "banner",
+		renditions: bannerRenditions,
+		set: func(ctx context.Context, q db.Querier, userID uuid.UUID, url string) (*string, error) {
+			return q.UpdateUserBanner(ctx, db.UpdateUserBannerParams{ID: userID, BannerUrl: &url})
+		},
+	}
+)
+
+// imageResponse lists the URL of every rendition of an upload, keyed by
+// size. URL is the one stored on the profile.
+type imageResponse struct {
+	URL        string            `json:"url"`
+	Renditions map[string]string `json:"renditions"`
+}
+
+// UploadAvatar handles POST /api/v1/users/me/avatar.
+func (h *Handler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	h.uploadImage(w, r, avatarSlot)
+}
+
+// UploadBanner handles POST /api/v1/users/me/banner.
+func (h *Handler) UploadBanner(w http.ResponseWriter, r *http.Request) {
+	h.uploadImage(w, r, bannerSlot)
+}
+
+// uploadImage takes a multipart upload in the "file" field, re-encodes it
+// to the slot's renditions under avatars/<user_id>/ and points the profile
+// at them. The files of the image it replaces are then deleted.
+func (h *Handler) uploadImage(w http.ResponseWriter, r *http.Request, slot imageSlot) {
+	ctx := r.Context()
+	userID, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+
+	// Leave room for the multipart framing around the file.
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImageBytes+64<<10)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			httpx.WriteTooLarge(w, MaxImageBytes)
+			return
+		}
+		httpx.WriteError(w, http.StatusBadRequest, "missing_file", `expected a multipart/form-data body with a "file" field`)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, MaxImageBytes+1))
+	if err != nil {
+		internalError(w, r, "failed to read upload", err)
+		return
+	}
+	if len(data) > MaxImageBytes {
+		httpx.WriteTooLarge(w, MaxImageBytes)
+		return
+	}
+	img, err := decodeImage(data)
+	if errors.Is(err, errUnsupportedImage) {
+		httpx.WriteError(w, http.StatusUnsupportedMediaType, "unsupported_image", err.Error())
+		return
+	}
+	if err != nil {
+		httpx.WriteError(w, http.StatusUnprocessableEntity, "image_too_large", err.Error())
+		return
+	}
+
+	prefix := fmt.Sprintf("avatars/%s/%s-%s-", userID, slot.name, uuid.New())
+	resp := imageResponse{Renditions: make(map[string]string, len(slot.renditions))}
+	for i, rd := range slot.renditions {
+		jpg, err := render(img, rd)
+		if err == nil {
+			err = h.media.put(ctx, prefix+rd.name+".jpg", jpg)
+		}
+		if err != nil {
+			h.removeImage(ctx, prefix)
+			internalError(w, r, "failed to store "+slot.name, err)
+			return
+		}
+		url := h.media.url(prefix + rd.name + ".jpg")
+		resp.Renditions[rd.name] = url
+		if i == 0 {
+			resp.URL = url
+		}
+	}
+
+	previous, err := slot.set(ctx, h.store, userID, resp.URL)
+	if err != nil {
+		h.removeImage(ctx, prefix)
+		if errors.Is(err, pgx.ErrNoRows) {
+			httpx.WriteError(w, http.StatusNotFound, "user_not_found", "your account no longer exists")
+			return
+		}
+		internalError(w, r, "failed to save "+slot.name, err)
+		return
+	}
+
+	if previous != nil {
+		if old, ok := h.renditionPrefix(userID, *previous); ok {
+			h.removeImage(ctx, old)
+		}
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+// renditionPrefix returns the key prefix shared by every rendition of the
+// upload that url points at. It refuses URLs outside the user's own
+// directory, so a bad column value can never delete someone else's files.
+func (h *Handler) renditionPrefix(userID uuid.UUID, url string) (string, bool) {
+	key, ok := h.media.key(url)
+	if !ok {
+		return "", false
+	}
+	dir := fmt.Sprintf("avatars/%s/", userID)
+	i := strings.LastIndex(key, "-")
+	if !strings.HasPrefix(key, dir) || i < len(dir) {
+		return "", false
+	}
+	return key[:i+1], true
+}
+
+// removeImage deletes an upload's files. Failures only leave orphaned
+// objects behind, so they are logged rather than reported.
+func (h *Handler) removeImage(ctx context.Context, prefix string) {
+	if err := h.media.removePrefix(context.WithoutCancel(ctx), prefix); err != nil {
+		logging.FromContext(ctx).With(logging.ModuleKey, "users").
+			Warn("failed to remove image", slog.String("prefix", prefix), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,306 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/httpx"
+	"github.com/jackc/pgx/v5"
+)
+
+// notifications.type values of the notices sent by this package.
+const (
+	notificationFollow        = "follow"
+	notificationFollowRequest = "follow_request"
+)
+
+// UserSummary is a user as listed in followers, following and mutuals.
+// IsFollowing is whether the viewer follows them, and is only set for
+// authenticated requests.
+type UserSummary struct {
+	ID          uuid.UUID `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	AvatarURL   *string   `json:"avatar_url"`
+	IsFollowing *bool     `json:"is_following,omitempty"`
+
+	// listedAt is the row's position in its list, for the next cursor.
+	listedAt time.Time
+}
+
+// UserPage is one page of a user list. NextCursor is empty on the last page.
+type UserPage struct {
+	Users      []UserSummary `json:"users"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// MutualsPage is a page of mutuals along with how many there are in all,
+// for "followed by a, b and N others".
+type MutualsPage struct {
+	UserPage
+	Count int64 `json:"count"`
+}
+
+// lookupUser resolves the {username} in the path, writing a 404 and
+// returning false if no one has it or its account is deactivated.
+func (h *Handler) lookupUser(w http.ResponseWriter, r *http.Request) (db.User, bool) {
+	user, err := h.store.GetUserByUsername(r.Context(), chi.URLParam(r, "username"))
+	if errors.Is(err, pgx.ErrNoRows) || err == nil && user.DeactivatedAt != nil {
+		httpx.WriteError(w, http.StatusNotFound, "user_not_found", "no user has that username")
+		return db.User{}, false
+	}
+	if err != nil {
+		internalError(w, r, "failed to look up user", err)
+		return db.User{}, false
+	}
+	return user, true
+}
+
+// lookupVisibleUser resolves the {username} in the path for a read of their
+// lists, writing a 404 where GetProfile would: if no one has it, its account
+// is deactivated or they have blocked viewer.
+func (h *Handler) lookupVisibleUser(w http.ResponseWriter, r *http.Request, viewer uuid.UUID) (uuid.UUID, bool) {
+	profile, err := h.store.GetUserProfile(r.Context(), db.GetUserProfileParams{
+		Username: chi.URLParam(r, "username"),
+		ViewerID: viewer,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		httpx.WriteError(w, http.StatusNotFound, "user_not_found", "no user has that username")
+		return uuid.Nil, false
+	}
+	if err != nil {
+		internalError(w, r, "failed to look up user", err)
+		return uuid.Nil, false
+	}
+	return profile.ID, true
+}
+
+// Follow handles POST /api/v1/users/{username}/follow. Following someone
+// already followed changes nothing and notifies no one. Following a private
+// account only asks to: the answer is 202 until they approve.
+func (h *Handler) Follow(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	viewer, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+	target, ok := h.lookupUser(w, r)
+	if !ok {
+		return
+	}
+	if target.ID == viewer {
+		httpx.WriteError(w, http.StatusBadRequest, "cannot_follow_self", "you cannot follow yourself")
+		return
+	}
+
+	var requested bool
+	err = h.store.InTx(ctx, func(q db.Querier) error {
+		// Privacy is read again inside the transaction so a follow can't
+		// race the account going public or private.
+		target, err := q.GetUserByID(ctx, target.ID)
+		if err != nil {
+			return err
+		}
+		if !target.IsPrivate {
+			requested = false
+			return follow(ctx, q, viewer, target.ID)
+		}
+		requested, err = requestFollow(ctx, q, viewer, target.ID)
+		return err
+	})
+	if db.IsBlocked(err) {
+		writeBlocked(w)
+		return
+	}
+	if err != nil {
+		internalError(w, r, "failed to follow user", err)
+		return
+	}
+	if requested {
+		httpx.WriteJSON(w, http.StatusAccepted, followResponse{Status: "requested"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// followResponse is the body of a follow that awaits approval.
+type followResponse struct {
+	Status string `json:"status"`
+}
+
+// follow records that follower follows target and notifies target, unless
+// the follow already existed.
+func follow(ctx context.Context, q db.Querier, follower, target uuid.UUID) error {
+	n, err := q.CreateFollow(ctx, db.CreateFollowParams{FollowerID: follower, FollowingID: target})
+	if err != nil || n == 0 {
+		return err
+	}
+	_, err = q.CreateNotification(ctx, db.CreateNotificationParams{
+		RecipientID: target,
+		ActorID:     follower,
+		Type:        notificationFollow,
+		EntityID:    follower,
+		EntityType:  "user",
+	})
+	return err
+}
+
+// Unfollow handles DELETE /api/v1/users/{username}/follow. The follow
+// notification goes with the follow, so toggling doesn't pile them up. It
+// also withdraws a pending follow request.
+func (h *Handler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	viewer, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+	target, ok := h.lookupUser(w, r)
+	if !ok {
+		return
+	}
+
+	err = h.store.InTx(ctx, func(q db.Querier) error {
+		return unfollow(ctx, q, viewer, target.ID)
+	})
+	if err != nil {
+		internalError(w, r, "failed to unfollow user", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unfollow removes follower's follow of target and any pending request to
+// follow them, each with its notification.
+func unfollow(ctx context.Context, q db.Querier, follower, target uuid.UUID) error {
+	if err := cancelFollowRequest(ctx, q, follower, target); err != nil {
+		return err
+	}
+	n, err := q.DeleteFollow(ctx, db.DeleteFollowParams{FollowerID: follower, FollowingID: target})
+	if err != nil || n == 0 {
+		return err
+	}
+	_, err = q.DeleteFollowNotification(ctx, db.DeleteFollowNotificationParams{RecipientID: target, ActorID: follower})
+	return err
+}
+
+// ListFollowers handles GET /api/v1/users/{username}/followers.
+func (h *Handler) ListFollowers(w http.ResponseWriter, r *http.Request) {
+	h.listUsers(w, r, func(ctx context.Context, viewer, user uuid.UUID, p pageRequest) ([]UserSummary, error) {
+		params := db.ListFollowersParams{ViewerID: viewer, UserID: user, PageSize: p.fetch()}
+		params.BeforeFollowedAt, params.BeforeID = p.before()
+		rows, err := h.store.ListFollowers(ctx, params)
+		users := make([]UserSummary, len(rows))
+		for i, row := range rows {
+			users[i] = UserSummary{
+				ID: row.ID, Username: row.Username, DisplayName: row.DisplayName, AvatarURL: row.AvatarUrl,
+				IsFollowing: followFlag(viewer, row.IsFollowing), listedAt: row.FollowedAt,
+			}
+		}
+		return users, err
+	})
+}
+
+// ListFollowing handles GET /api/v1/users/{username}/following.
+func (h *Handler) ListFollowing(w http.ResponseWriter, r *http.Request) {
+	h.listUsers(w, r, func(ctx context.Context, viewer, user uuid.UUID, p pageRequest) ([]UserSummary, error) {
+		params := db.ListFollowingParams{ViewerID: viewer, UserID: user, PageSize: p.fetch()}
+		params.BeforeFollowedAt, params.BeforeID = p.before()
+		rows, err := h.store.ListFollowing(ctx, params)
+		users := make([]UserSummary, len(rows))
+		for i, row := range rows {
+			users[i] = UserSummary{
+				ID: row.ID, Username: row.Username, DisplayName: row.DisplayName, AvatarURL: row.AvatarUrl,
+				IsFollowing: followFlag(viewer, row.IsFollowing), listedAt: row.FollowedAt,
+			}
+		}
+		return users, err
+	})
+}
+
+// followFlag is the IsFollowing of a listed user: unset for anonymous
+// viewers, who follow no one.
+func followFlag(viewer uuid.UUID, following bool) *bool {
+	if viewer == uuid.Nil {
+		return nil
+	}
+	return &following
+}
+
+// listUsers serves a page of one of {username}'s lists, as fetched by list.
+func (h *Handler) listUsers(w http.ResponseWriter, r *http.Request, list func(ctx context.Context, viewer, user uuid.UUID, p pageRequest) ([]UserSummary, error)) {
+	// is_following depends on who is asking.
+	w.Header().Add("Vary", "Authorization")
+
+	viewer, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	userID, ok := h.lookupVisibleUser(w, r, viewer)
+	if !ok {
+		return
+	}
+
+	users, err := list(r.Context(), viewer, userID, page)
+	if err != nil {
+		internalError(w, r, "failed to list users", err)
+		return
+	}
+	users, next := trimPage(page, users)
+	httpx.WriteJSON(w, http.StatusOK, UserPage{Users: users, NextCursor: next})
+}
+
+// ListMutuals handles GET /api/v1/users/{username}/mutuals: the followers of
+// {username} that the viewer follows.
+func (h *Handler) ListMutuals(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	viewer, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	userID, ok := h.lookupVisibleUser(w, r, viewer)
+	if !ok {
+		return
+	}
+
+	params := db.ListMutualFollowersParams{ViewerID: viewer, UserID: userID, PageSize: page.fetch()}
+	params.BeforeFollowedAt, params.BeforeID = page.before()
+	rows, err := h.store.ListMutualFollowers(ctx, params)
+	if err != nil {
+		internalError(w, r, "failed to list mutuals", err)
+		return
+	}
+	count, err := h.store.CountMutualFollowers(ctx, db.CountMutualFollowersParams{ViewerID: viewer, UserID: userID})
+	if err != nil {
+		internalError(w, r, "failed to count mutuals", err)
+		return
+	}
+
+	// Mutuals are followed by definition.
+	following := true
+	users := make([]UserSummary, len(rows))
+	for i, row := range rows {
+		users[i] = UserSummary{
+			ID: row.ID, Username: row.Username, DisplayName: row.DisplayName, AvatarURL: row.AvatarUrl,
+			IsFollowing: &following, listedAt: row.FollowedAt,
+		}
+	}
+	users, next := trimPage(page, users)
+	httpx.WriteJSON(w, http.StatusOK, MutualsPage{UserPage: UserPage{Users: users, NextCursor: next}, Count: count})
+}
@@ -0,0 +1,389 @@
+package users_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/middleware"
+	"github.com/hrutav-modha/social-media-app/server/internal/testutil"
+	"github.com/hrutav-modha/social-media-app/server/internal/users"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// followStore keeps follows, follow requests and their notifications in
+// memory.
+type followStore struct {
+	db.Querier
+	users         map[string]db.User
+	follows       map[[2]uuid.UUID]bool
+	requests      map[[2]uuid.UUID]bool
+	blocks        map[[2]uuid.UUID]bool
+	mutes         map[[2]uuid.UUID]*time.Time
+	notifications []db.Notification
+}
+
+func newFollowStore(users ...db.User) *followStore {
+	f := &followStore{
+		users:    map[string]db.User{},
+		follows:  map[[2]uuid.UUID]bool{},
+		requests: map[[2]uuid.UUID]bool{},
+		blocks:   map[[2]uuid.UUID]bool{},
+		mutes:    map[[2]uuid.UUID]*time.Time{},
+	}
+	for _, u := range users {
+		f.users[u.Username] = u
+	}
+	return f
+}
+
+// notified counts the notifications of type typ from edge[0] to edge[1].
+func (f *followStore) notified(edge [2]uuid.UUID, typ string) int {
+	n := 0
+	for _, notice := range f.notifications {
+		if notice.ActorID == edge[0] && notice.RecipientID == edge[1] && notice.Type == typ {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *followStore) deleteNotifications(recipient, actor uuid.UUID, typ string) int64 {
+	kept := f.notifications[:0]
+	for _, notice := range f.notifications {
+		if notice.RecipientID != recipient || notice.ActorID != actor || notice.Type != typ {
+			kept = append(kept, notice)
+		}
+	}
+	n := len(f.notifications) - len(kept)
+	f.notifications = kept
+	return int64(n)
+}
+
+func (f *followStore) InTx(ctx context.Context, fn func(db.Querier) error) error {
+	return fn(f)
+}
+
+func (f *followStore) GetUserByUsername(ctx context.Context, username string) (db.User, error) {
+	u, ok := f.users[username]
+	if !ok {
+		return db.User{}, pgx.ErrNoRows
+	}
+	return u, nil
+}
+
+func (f *followStore) GetUserByID(ctx context.Context, id uuid.UUID) (db.User, error) {
+	for _, u := range f.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return db.User{}, pgx.ErrNoRows
+}
+
+func (f *followStore) IsFollowing(ctx context.Context, arg db.IsFollowingParams) (bool, error) {
+	return f.follows[[2]uuid.UUID{arg.FollowerID, arg.FollowingID}], nil
+}
+
+// toggle sets m[key] to v, reporting 1 if that changed it.
+func toggle(m map[[2]uuid.UUID]bool, key [2]uuid.UUID, v bool) int64 {
+	if m[key] == v {
+		return 0
+	}
+	if v {
+		m[key] = true
+	} else {
+		delete(m, key)
+	}
+	return 1
+}
+
+// checkBlocked fails like the reject_blocked trigger.
+func (f *followStore) checkBlocked(a, b uuid.UUID) error {
+	if f.blocks[[2]uuid.UUID{a, b}] || f.blocks[[2]uuid.UUID{b, a}] {
+		return &pgconn.PgError{Code: "23514", ConstraintName: "blocked"}
+	}
+	return nil
+}
+
+func (f *followStore) CreateFollow(ctx context.Context, arg db.CreateFollowParams) (int64, error) {
+	if err := f.checkBlocked(arg.FollowerID, arg.FollowingID); err != nil {
+		return 0, err
+	}
+	return toggle(f.follows, [2]uuid.UUID{arg.FollowerID, arg.FollowingID}, true), nil
+}
+
+func (f *followStore) DeleteFollow(ctx context.Context, arg db.DeleteFollowParams) (int64, error) {
+	return toggle(f.follows, [2]uuid.UUID{arg.FollowerID, arg.FollowingID}, false), nil
+}
+
+func (f *followStore) CreateFollowRequest(ctx context.Context, arg db.CreateFollowRequestParams) (int64, error) {
+	if err := f.checkBlocked(arg.RequesterID, arg.TargetID); err != nil {
+		return 0, err
+	}
+	return toggle(f.requests, [2]uuid.UUID{arg.RequesterID, arg.TargetID}, true), nil
+}
+
+func (f *followStore) DeleteFollowRequest(ctx context.Context, arg db.DeleteFollowRequestParams) (int64, error) {
+	return toggle(f.requests, [2]uuid.UUID{arg.RequesterID, arg.TargetID}, false), nil
+}
+
+func (f *followStore) CreateBlock(ctx context.Context, arg db.CreateBlockParams) (int64, error) {
+	return toggle(f.blocks, [2]uuid.UUID{arg.BlockerID, arg.BlockedID}, true), nil
+}
+
+func (f *followStore) DeleteBlock(ctx context.Context, arg db.DeleteBlockParams) (int64, error) {
+	return toggle(f.blocks, [2]uuid.UUID{arg.BlockerID, arg.BlockedID}, false), nil
+}
+
+func (f *followStore) UpsertMute(ctx context.Context, arg db.UpsertMuteParams) error {
+	f.mutes[[2]uuid.UUID{arg.MuterID, arg.MutedID}] = arg.ExpiresAt
+	return nil
+}
+
+func (f *followStore) DeleteMute(ctx context.Context, arg db.DeleteMuteParams) (int64, error) {
+	key := [2]uuid.UUID{arg.MuterID, arg.MutedID}
+	_, ok := f.mutes[key]
+	delete(f.mutes, key)
+	if !ok {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+func (f *followStore) CreateNotification(ctx context.Context, arg db.CreateNotificationParams) (db.Notification, error) {
+	notice := db.Notification{RecipientID: arg.RecipientID, ActorID: arg.ActorID, Type: arg.Type}
+	f.notifications = append(f.notifications, notice)
+	return notice, nil
+}
+
+func (f *followStore) DeleteFollowNotification(ctx context.Context, arg db.DeleteFollowNotificationParams) (int64, error) {
+	return f.deleteNotifications(arg.RecipientID, arg.ActorID, "follow"), nil
+}
+
+func (f *followStore) DeleteFollowRequestNotification(ctx context.Context, arg db.DeleteFollowRequestNotificationParams) (int64, error) {
+	return f.deleteNotifications(arg.RecipientID, arg.ActorID, "follow_request"), nil
+}
+
+func (f *followStore) PromoteFollowRequestNotifications(ctx context.Context, arg db.PromoteFollowRequestNotificationsParams) (int64, error) {
+	var n int64
+	for i, notice := range f.notifications {
+		if notice.RecipientID == arg.RecipientID && notice.Type == "follow_request" && (arg.ActorID == nil || notice.ActorID == *arg.ActorID) {
+			f.notifications[i].Type = "follow"
+			n++
+		}
+	}
+	return n, nil
+}
+
+// relationRouter mounts the follow, block and mute routes on store and
+// returns a function that sends a request as a user, with an optional JSON
+// body, and returns the status.
+func relationRouter(t *testing.T, store db.Store) func(as db.User, method, path string, body ...string) int {
+	t.Helper()
+	h := users.NewHandler(store, testutil.NewClock(testutil.Epoch), users.Media{}, nil, nil)
+	_, tokens := newRouter(t, h)
+	r := chi.NewRouter()
+	r.Use(middleware.Auth(tokens))
+	r.Post("/users/{username}/follow", h.Follow)
+	r.Delete("/users/{username}/follow", h.Unfollow)
+	r.Post("/users/me/follow-requests/{username}/approve", h.ApproveFollowRequest)
+	r.Post("/users/me/follow-requests/{username}/reject", h.RejectFollowRequest)
+	r.Post("/users/{username}/block", h.Block)
+	r.Delete("/users/{username}/block", h.Unblock)
+	r.Post("/users/{username}/mute", h.Mute)
+	r.Delete("/users/{username}/mute", h.Unmute)
+
+	return func(as db.User, method, path string, body ...string) int {
+		token, err := tokens.GenerateAccessToken(as.ID.String())
+		require.NoError(t, err)
+		req := httptest.NewRequest(method, path, strings.NewReader(strings.Join(body, "")))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+}
+
+func TestFollow(t *testing.T) {
+	alice := db.User{ID: uuid.New(), Username: "alice"}
+	bob := db.User{ID: uuid.New(), Username: "bob"}
+	carol := db.User{ID: uuid.New(), Username: "carol", IsPrivate: true}
+	store := newFollowStore(alice, bob, carol)
+	do := relationRouter(t, store)
+	edge := [2]uuid.UUID{bob.ID, alice.ID}
+
+	t.Run("Following twice notifies once", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, do(bob, http.MethodPost, "/users/alice/follow"))
+		assert.Equal(t, http.StatusNoContent, do(bob, http.MethodPost, "/users/alice/follow"))
+		assert.True(t, store.follows[edge])
+		assert.Equal(t, 1, store.notified(edge, "follow"))
+	})
+
+	t.Run("Unfollowing withdraws the notification", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, do(bob, http.MethodDelete, "/users/alice/follow"))
+		assert.Equal(t, http.StatusNoContent, do(bob, http.MethodDelete, "/users/alice/follow"))
+		assert.False(t, store.follows[edge])
+		assert.Zero(t, store.notified(edge, "follow"))
+
+		assert.Equal(t, http.StatusNoContent, do(bob, http.MethodPost, "/users/alice/follow"))
+		assert.Equal(t, 1, store.notified(edge, "follow"), "refollowing doesn't stack notifications")
+	})
+
+	t.Run("Self and unknown users", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do(bob, http.MethodPost, "/users/bob/follow"))
+		assert.Equal(t, http.StatusNotFound, do(bob, http.MethodPost, "/users/nobody/follow"))
+		assert.Equal(t, http.StatusNotFound, do(bob, http.MethodDelete, "/users/nobody/follow"))
+	})
+
+	t.Run("Private accounts get requests", func(t *testing.T) {
+		edge := [2]uuid.UUID{bob.ID, carol.ID}
+		assert.Equal(t, http.StatusAccepted, do(bob, http.MethodPost, "/users/carol/follow"))
+		assert.Equal(t, http.StatusAccepted, do(bob, http.MethodPost, "/users/carol/follow"))
+		assert.False(t, store.follows[edge])
+		assert.True(t, store.requests[edge])
+		assert.Equal(t, 1, store.notified(edge, "follow_request"))
+
+		assert.Equal(t, http.StatusNoContent, do(bob, http.MethodDelete, "/users/carol/follow"))
+		assert.False(t, store.requests[edge], "unfollowing cancels the request")
+		assert.Zero(t, store.notified(edge, "follow_request"))
+	})
+
+	t.Run("Approving turns the request into a follow", func(t *testing.T) {
+		edge := [2]uuid.UUID{bob.ID, carol.ID}
+		require.Equal(t, http.StatusAccepted, do(bob, http.MethodPost, "/users/carol/follow"))
+		assert.Equal(t, http.StatusNoContent, do(carol, http.MethodPost, "/users/me/follow-requests/bob/approve"))
+		assert.True(t, store.follows[edge])
+		assert.False(t, store.requests[edge])
+		assert.Zero(t, store.notified(edge, "follow_request"))
+		assert.Equal(t, 1, store.notified(edge, "follow"))
+
+		assert.Equal(t, http.StatusNotFound, do(carol, http.MethodPost, "/users/me/follow-requests/bob/approve"))
+		assert.Equal(t, http.StatusNoContent, do(bob, http.MethodPost, "/users/carol/follow"), "followers are not asked again")
+	})
+
+	t.Run("Rejecting drops the request", func(t *testing.T) {
+		edge := [2]uuid.UUID{alice.ID, carol.ID}
+		require.Equal(t, http.StatusAccepted, do(alice, http.MethodPost, "/users/carol/follow"))
+		assert.Equal(t, http.StatusNoContent, do(carol, http.MethodPost, "/users/me/follow-requests/alice/reject"))
+		assert.False(t, store.follows[edge])
+		assert.False(t, store.requests[edge])
+		assert.Zero(t, store.notified(edge, "follow_request"))
+		assert.Equal(t, http.StatusNotFound, do(carol, http.MethodPost, "/users/me/follow-requests/alice/reject"))
+	})
+
+	t.Run("Requests can't be approved across a block", func(t *testing.T) {
+		edge := [2]uuid.UUID{alice.ID, carol.ID}
+		require.Equal(t, http.StatusAccepted, do(alice, http.MethodPost, "/users/carol/follow"))
+		store.blocks[edge] = true
+		defer delete(store.blocks, edge)
+		assert.Equal(t, http.StatusForbidden, do(carol, http.MethodPost, "/users/me/follow-requests/alice/approve"))
+		assert.False(t, store.follows[edge])
+	})
+}
+
+func TestFollowAPI(t *testing.T) {
+	s := testutil.NewServer(t)
+	alice := s.RegisterUser(t, "alice")
+	bob := s.RegisterUser(t, "bob")
+	carol := s.RegisterUser(t, "carol")
+	var fans []*testutil.User
+	for i := range 5 {
+		fan := s.RegisterUser(t, fmt.Sprintf("fan%d", i))
+		require.Equal(t, http.StatusNoContent, s.Do(t, http.MethodPost, "/api/v1/users/alice/follow", nil, fan).StatusCode)
+		fans = append(fans, fan)
+	}
+
+	for _, u := range []*testutil.User{bob, carol} {
+		resp := s.Do(t, http.MethodPost, "/api/v1/users/alice/follow", nil, u)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	}
+	resp := s.Do(t, http.MethodPost, "/api/v1/users/carol/follow", nil, bob)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	t.Run("Follows notify in the same transaction", func(t *testing.T) {
+		n, err := s.App.Store.CountUnreadNotifications(context.Background(), db.CountUnreadNotificationsParams{
+			RecipientID: uuid.MustParse(alice.ID),
+			Now:         s.Clock.Now(),
+		})
+		require.NoError(t, err)
+		assert.EqualValues(t, 7, n)
+	})
+
+	t.Run("Followers page by cursor", func(t *testing.T) {
+		var seen []string
+		path := "/api/v1/users/alice/followers?limit=3"
+		for {
+			resp := s.Do(t, http.MethodGet, path, nil, bob)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			var page users.UserPage
+			testutil.DecodeJSON(t, resp, &page)
+			for _, u := range page.Users {
+				seen = append(seen, u.Username)
+				require.NotNil(t, u.IsFollowing)
+				assert.Equal(t, u.Username == "carol", *u.IsFollowing, u.Username)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			path = "/api/v1/users/alice/followers?limit=3&cursor=" + page.NextCursor
+		}
+		assert.Len(t, seen, 7)
+		assert.ElementsMatch(t, []string{"bob", "carol", "fan0", "fan1", "fan2", "fan3", "fan4"}, seen)
+	})
+
+	t.Run("Anonymous lists carry no flags", func(t *testing.T) {
+		resp := s.Do(t, http.MethodGet, "/api/v1/users/bob/following", nil, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var page users.UserPage
+		testutil.DecodeJSON(t, resp, &page)
+		require.Len(t, page.Users, 2)
+		assert.Nil(t, page.Users[0].IsFollowing)
+	})
+
+	t.Run("Mutuals are followers the viewer follows", func(t *testing.T) {
+		resp := s.Do(t, http.MethodGet, "/api/v1/users/alice/mutuals", nil, bob)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var page users.MutualsPage
+		testutil.DecodeJSON(t, resp, &page)
+		assert.EqualValues(t, 1, page.Count)
+		require.Len(t, page.Users, 1)
+		assert.Equal(t, "carol", page.Users[0].Username)
+
+		resp = s.Do(t, http.MethodGet, "/api/v1/users/alice/mutuals", nil, nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Unfollowing removes the notification", func(t *testing.T) {
+		for range 2 {
+			resp := s.Do(t, http.MethodDelete, "/api/v1/users/alice/follow", nil, fans[0])
+			assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		}
+		n, err := s.App.Store.CountUnreadNotifications(context.Background(), db.CountUnreadNotificationsParams{
+			RecipientID: uuid.MustParse(alice.ID),
+			Now:         s.Clock.Now(),
+		})
+		require.NoError(t, err)
+		assert.EqualValues(t, 6, n)
+
+		resp := s.Do(t, http.MethodGet, "/api/v1/users/alice", nil, nil)
+		var profile users.Profile
+		testutil.DecodeJSON(t, resp, &profile)
+		assert.EqualValues(t, 6, profile.FollowerCount)
+	})
+
+	t.Run("Bad cursors are rejected", func(t *testing.T) {
+		resp := s.Do(t, http.MethodGet, "/api/v1/users/alice/followers?cursor=nope", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
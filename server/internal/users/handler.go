@@ -0,0 +1,54 @@
+// Package users serves the /api/v1/users routes: public profiles, the
+// viewer's relationship to them and changes to the viewer's own account.
+package users
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/auth"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/httpx"
+	"github.com/hrutav-modha/social-media-app/server/internal/logging"
+	"github.com/hrutav-modha/social-media-app/server/internal/middleware"
+)
+
+// Handler serves the user routes from a db.Store.
+type Handler struct {
+	store       db.Store
+	clock       auth.Clock
+	media       Media
+	suggestions *Suggestions
+	accounts    *Accounts
+}
+
+// NewHandler returns a Handler backed by store, keeping profile images in
+// media, serving follow suggestions from suggestions and deactivating or
+// deleting accounts through accounts. clock decides cooldowns, how long old
+// usernames redirect and when mutes expire.
+func NewHandler(store db.Store, clock auth.Clock, media Media, suggestions *Suggestions, accounts *Accounts) *Handler {
+	return &Handler{store: store, clock: clock, media: media, suggestions: suggestions, accounts: accounts}
+}
+
+// viewerID returns the authenticated user's ID, or uuid.Nil when the
+// request is anonymous.
+func viewerID(r *http.Request) (uuid.UUID, error) {
+	id := middleware.GetUserID(r.Context())
+	if id == "" {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(id)
+}
+
+func writeInvalidToken(w http.ResponseWriter) {
+	httpx.WriteError(w, http.StatusUnauthorized, "invalid_token", "token subject is not a user ID")
+}
+
+// internalError logs err and writes a generic 500, keeping the details out
+// of the response.
+func internalError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	logging.FromContext(r.Context()).With(logging.ModuleKey, "users").
+		Error(msg, slog.Any("error", err))
+	httpx.WriteError(w, http.StatusInternalServerError, "internal_error", "something went wrong")
+}
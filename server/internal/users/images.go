@@ -0,0 +1,102 @@
+package users
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+
+	// Decoders for the accepted upload formats.
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// MaxImageBytes caps avatar and banner uploads.
+	MaxImageBytes = 5 << 20
+
+	// maxImagePixels bounds the decoded size, so a small file that
+	// decompresses to a huge bitmap is refused before it is decoded.
+	maxImagePixels = 40_000_000
+
+	jpegQuality = 85
+)
+
+var errUnsupportedImage = errors.New("images must be JPEG, PNG, GIF or WebP")
+
+// allowedImageTypes are the content types accepted for uploads, as sniffed
+// from the file's leading bytes; the client's Content-Type is ignored.
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// rendition is one size an uploaded image is re-encoded to.
+type rendition struct {
+	name          string
+	width, height int
+}
+
+var (
+	// avatarRenditions are square; the first is stored in avatar_url and
+	// the others differ only in the -<name>.jpg suffix.
+	avatarRenditions = []rendition{{"400", 400, 400}, {"200", 200, 200}, {"48", 48, 48}}
+
+	// bannerRenditions are 3:1, the first stored in banner_url.
+	bannerRenditions = []rendition{{"1500x500", 1500, 500}, {"600x200", 600, 200}}
+)
+
+// decodeImage checks data's magic bytes and dimensions and decodes it. Only
+// the first frame of an animated GIF is kept.
+func decodeImage(data []byte) (image.Image, error) {
+	if !allowedImageTypes[http.DetectContentType(data)] {
+		return nil, errUnsupportedImage
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errUnsupportedImage
+	}
+	if cfg.Width == 0 || cfg.Height == 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, fmt.Errorf("images must be at most %d megapixels", maxImagePixels/1_000_000)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errUnsupportedImage
+	}
+	return img, nil
+}
+
+// render crops the centre of src to r's aspect ratio, scales it to r's size
+// and encodes it as JPEG. Re-encoding also drops any metadata, such as EXIF
+// location, that came with the upload. Transparent areas become white.
+func render(src image.Image, r rendition) ([]byte, error) {
+	b := src.Bounds()
+	crop := b
+	if b.Dx()*r.height > b.Dy()*r.width {
+		w := max(b.Dy()*r.width/r.height, 1)
+		crop.Min.X = b.Min.X + (b.Dx()-w)/2
+		crop.Max.X = crop.Min.X + w
+	} else {
+		h := max(b.Dx()*r.height/r.width, 1)
+		crop.Min.Y = b.Min.Y + (b.Dy()-h)/2
+		crop.Max.Y = crop.Min.Y + h
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, r.width, r.height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
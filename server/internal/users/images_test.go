@@ -0,0 +1,73 @@
+package users
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := range w {
+		img.Set(x, 0, color.NRGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestDecodeImage(t *testing.T) {
+	t.Run("Formats are sniffed from the content", func(t *testing.T) {
+		img, err := decodeImage(encodePNG(t, 30, 20))
+		require.NoError(t, err)
+		assert.Equal(t, 30, img.Bounds().Dx())
+
+		_, err = decodeImage([]byte("<svg xmlns='http://www.w3.org/2000/svg'></svg>"))
+		assert.ErrorIs(t, err, errUnsupportedImage)
+	})
+
+	t.Run("Truncated images are rejected", func(t *testing.T) {
+		data := encodePNG(t, 30, 20)
+		_, err := decodeImage(data[:len(data)/2])
+		assert.ErrorIs(t, err, errUnsupportedImage)
+	})
+
+	t.Run("Decompression bombs are refused before decoding", func(t *testing.T) {
+		data := encodePNG(t, 1, 1)
+		// Rewrite the IHDR chunk to claim 100000x100000 pixels.
+		ihdr := data[8+8 : 8+8+13]
+		binary.BigEndian.PutUint32(ihdr[0:4], 100000)
+		binary.BigEndian.PutUint32(ihdr[4:8], 100000)
+		binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(data[8+4:8+8+13]))
+
+		_, err := decodeImage(data)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, errUnsupportedImage)
+	})
+}
+
+func TestRender(t *testing.T) {
+	src, err := decodeImage(encodePNG(t, 300, 100))
+	require.NoError(t, err)
+
+	for _, r := range append(avatarRenditions, bannerRenditions...) {
+		data, err := render(src, r)
+		require.NoError(t, err)
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		require.NoError(t, err, r.name)
+		assert.Equal(t, r.width, cfg.Width, r.name)
+		assert.Equal(t, r.height, cfg.Height, r.name)
+	}
+
+	_, err = render(image.NewRGBA(image.Rect(0, 0, 1, 1)), bannerRenditions[0])
+	assert.NoError(t, err, "tiny images are scaled up")
+}
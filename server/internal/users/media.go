@@ -0,0 +1,49 @@
+package users
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Media is the bucket profile images are stored in. Objects are publicly
+// readable under BaseURL, so their URLs can be handed to clients as is.
+type Media struct {
+	Client  *minio.Client
+	Bucket  string
+	BaseURL string
+}
+
+// url returns the public URL of the object at key.
+func (m Media) url(key string) string {
+	return strings.TrimSuffix(m.BaseURL, "/") + "/" + key
+}
+
+// key reverses url. It fails for URLs from elsewhere, such as ones stored
+// before BaseURL changed.
+func (m Media) key(url string) (string, bool) {
+	return strings.CutPrefix(url, strings.TrimSuffix(m.BaseURL, "/")+"/")
+}
+
+// put stores a JPEG. Keys are never reused, so clients may cache forever.
+func (m Media) put(ctx context.Context, key string, data []byte) error {
+	_, err := m.Client.PutObject(ctx, m.Bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType:  "image/jpeg",
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	return err
+}
+
+// removePrefix deletes every object whose key starts with prefix.
+func (m Media) removePrefix(ctx context.Context, prefix string) error {
+	objects := m.Client.ListObjects(ctx, m.Bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true})
+	var err error
+	for e := range m.Client.RemoveObjects(ctx, m.Bucket, objects, minio.RemoveObjectsOptions{}) {
+		if err == nil {
+			err = e.Err
+		}
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+package users
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/httpx"
+)
+
+// Page sizes for the user lists.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// cursor marks the last row of a page. Lists are ordered newest first with
+// the ID breaking ties, so the next page is everything before (At, ID).
+type cursor struct {
+	At time.Time
+	ID uuid.UUID
+}
+
+// String encodes c as an opaque token for the next_cursor field.
+func (c cursor) String() string {
+	raw := strconv.FormatInt(c.At.UnixMicro(), 10) + ":" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+func parseCursor(s string) (cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, errInvalidCursor
+	}
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return cursor{}, errInvalidCursor
+	}
+	us, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return cursor{}, errInvalidCursor
+	}
+	c := cursor{At: time.UnixMicro(us).UTC()}
+	if c.ID, err = uuid.Parse(id); err != nil {
+		return cursor{}, errInvalidCursor
+	}
+	return c, nil
+}
+
+// pageRequest is a list request's ?cursor= and ?limit=.
+type pageRequest struct {
+	after *cursor
+	limit int
+}
+
+// before returns the keyset bounds for the query, nil on the first page.
+func (p pageRequest) before() (*time.Time, *uuid.UUID) {
+	if p.after == nil {
+		return nil, nil
+	}
+	return &p.after.At, &p.after.ID
+}
+
+// fetch is the page size to query: one more than limit, so a full page
+// shows whether there is another.
+func (p pageRequest) fetch() int32 { return int32(p.limit + 1) }
+
+// parsePage reads the paging parameters, writing a 400 and returning false
+// if they are malformed.
+func parsePage(w http.ResponseWriter, r *http.Request) (pageRequest, bool) {
+	limit, ok := parseLimit(w, r, DefaultPageSize, MaxPageSize)
+	if !ok {
+		return pageRequest{}, false
+	}
+	p := pageRequest{limit: limit}
+	if s := r.URL.Query().Get("cursor"); s != "" {
+		c, err := parseCursor(s)
+		if err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, "invalid_cursor", "cursor is malformed")
+			return pageRequest{}, false
+		}
+		p.after = &c
+	}
+	return p, true
+}
+
+// parseLimit reads ?limit=, which defaults to def and may be at most max,
+// writing a 400 and returning false if it is out of range.
+func parseLimit(w http.ResponseWriter, r *http.Request, def, max int) (int, bool) {
+	s := r.URL.Query().Get("limit")
+	if s == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > max {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid_limit", "limit must be between 1 and "+strconv.Itoa(max))
+		return 0, false
+	}
+	return n, true
+}
+
+// trimPage cuts users fetched with p.fetch() down to p.limit, returning
+// the cursor for the next page if there is one.
+func trimPage(p pageRequest, users []UserSummary) ([]UserSummary, string) {
+	if len(users) <= p.limit {
+		return users, ""
+	}
+	users = users[:p.limit]
+	last := users[len(users)-1]
+	return users, cursor{At: last.listedAt, ID: last.ID}.String()
+}
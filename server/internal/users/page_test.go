@@ -0,0 +1,56 @@
+package users
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePage(t *testing.T) {
+	c := cursor{At: time.Date(2025, 1, 1, 12, 0, 0, 123456000, time.UTC), ID: uuid.New()}
+
+	tests := []struct {
+		query string
+		ok    bool
+		want  pageRequest
+	}{
+		{"", true, pageRequest{limit: DefaultPageSize}},
+		{"limit=5&cursor=" + c.String(), true, pageRequest{limit: 5, after: &c}},
+		{"limit=0", false, pageRequest{}},
+		{"limit=101", false, pageRequest{}},
+		{"limit=ten", false, pageRequest{}},
+		{"cursor=!!", false, pageRequest{}},
+		{"cursor=bm9wZQ", false, pageRequest{}}, // "nope"
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		got, ok := parsePage(w, httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil))
+		require.Equal(t, tt.ok, ok, tt.query)
+		if !ok {
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			continue
+		}
+		assert.Equal(t, tt.want, got, tt.query)
+	}
+}
+
+func TestTrimPage(t *testing.T) {
+	p := pageRequest{limit: 2}
+	page := []UserSummary{{ID: uuid.New()}, {ID: uuid.New(), listedAt: time.Unix(100, 0)}, {ID: uuid.New()}}
+
+	got, next := trimPage(p, page[:2])
+	assert.Len(t, got, 2)
+	assert.Empty(t, next, "a short page is the last")
+
+	got, next = trimPage(p, page)
+	assert.Len(t, got, 2)
+	c, err := parseCursor(next)
+	require.NoError(t, err)
+	assert.Equal(t, page[1].ID, c.ID)
+	assert.True(t, c.At.Equal(page[1].listedAt))
+}
@@ -0,0 +1,167 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/httpx"
+)
+
+// requestFollow asks a private target to let requester follow them and
+// notifies target of the request. It reports whether a request is now
+// pending: nothing is asked of someone requester already follows.
+func requestFollow(ctx context.Context, q db.Querier, requester, target uuid.UUID) (bool, error) {
+	following, err := q.IsFollowing(ctx, db.IsFollowingParams{FollowerID: requester, FollowingID: target})
+	if err != nil || following {
+		return false, err
+	}
+	n, err := q.CreateFollowRequest(ctx, db.CreateFollowRequestParams{RequesterID: requester, TargetID: target})
+	if err != nil || n == 0 {
+		return true, err
+	}
+	_, err = q.CreateNotification(ctx, db.CreateNotificationParams{
+		RecipientID: target,
+		ActorID:     requester,
+		Type:        notificationFollowRequest,
+		EntityID:    requester,
+		EntityType:  "user",
+	})
+	return true, err
+}
+
+// cancelFollowRequest withdraws requester's pending request to follow
+// target, if any, along with its notification.
+func cancelFollowRequest(ctx context.Context, q db.Querier, requester, target uuid.UUID) error {
+	n, err := q.DeleteFollowRequest(ctx, db.DeleteFollowRequestParams{RequesterID: requester, TargetID: target})
+	if err != nil || n == 0 {
+		return err
+	}
+	_, err = q.DeleteFollowRequestNotification(ctx, db.DeleteFollowRequestNotificationParams{RecipientID: target, ActorID: requester})
+	return err
+}
+
+// setPrivacy makes userID's account private or public. Going public
+// approves every pending request, since nothing is left to approve.
+func setPrivacy(ctx context.Context, q db.Querier, userID uuid.UUID, private bool) error {
+	wasPrivate, err := q.UpdateUserPrivacy(ctx, db.UpdateUserPrivacyParams{IsPrivate: private, ID: userID})
+	if err != nil || private || !wasPrivate {
+		return err
+	}
+	if _, err := q.AcceptAllFollowRequests(ctx, userID); err != nil {
+		return err
+	}
+	_, err = q.PromoteFollowRequestNotifications(ctx, db.PromoteFollowRequestNotificationsParams{RecipientID: userID})
+	return err
+}
+
+// ListFollowRequests handles GET /api/v1/users/me/follow-requests: the
+// accounts waiting for the viewer's approval, most recent first.
+func (h *Handler) ListFollowRequests(w http.ResponseWriter, r *http.Request) {
+	viewer, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	params := db.ListFollowRequestsParams{TargetID: viewer, PageSize: page.fetch()}
+	params.BeforeRequestedAt, params.BeforeID = page.before()
+	rows, err := h.store.ListFollowRequests(r.Context(), params)
+	if err != nil {
+		internalError(w, r, "failed to list follow requests", err)
+		return
+	}
+
+	users := make([]UserSummary, len(rows))
+	for i, row := range rows {
+		users[i] = UserSummary{
+			ID: row.ID, Username: row.Username, DisplayName: row.DisplayName, AvatarURL: row.AvatarUrl,
+			listedAt: row.RequestedAt,
+		}
+	}
+	users, next := trimPage(page, users)
+	httpx.WriteJSON(w, http.StatusOK, UserPage{Users: users, NextCursor: next})
+}
+
+// errNoFollowRequest means the user to approve or reject has not asked to
+// follow the viewer.
+var errNoFollowRequest = errors.New("no pending follow request")
+
+// ApproveFollowRequest handles
+// POST /api/v1/users/me/follow-requests/{username}/approve. The request's
+// notification becomes an ordinary follow notification.
+func (h *Handler) ApproveFollowRequest(w http.ResponseWriter, r *http.Request) {
+	h.answerFollowRequest(w, r, func(ctx context.Context, q db.Querier, viewer, requester uuid.UUID) error {
+		n, err := q.DeleteFollowRequest(ctx, db.DeleteFollowRequestParams{RequesterID: requester, TargetID: viewer})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errNoFollowRequest
+		}
+		if _, err := q.CreateFollow(ctx, db.CreateFollowParams{FollowerID: requester, FollowingID: viewer}); err != nil {
+			return err
+		}
+		_, err = q.PromoteFollowRequestNotifications(ctx, db.PromoteFollowRequestNotificationsParams{
+			RecipientID: viewer,
+			ActorID:     &requester,
+		})
+		return err
+	})
+}
+
+// RejectFollowRequest handles
+// POST /api/v1/users/me/follow-requests/{username}/reject. The requester is
+// not told, and may ask again.
+func (h *Handler) RejectFollowRequest(w http.ResponseWriter, r *http.Request) {
+	h.answerFollowRequest(w, r, func(ctx context.Context, q db.Querier, viewer, requester uuid.UUID) error {
+		n, err := q.DeleteFollowRequest(ctx, db.DeleteFollowRequestParams{RequesterID: requester, TargetID: viewer})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errNoFollowRequest
+		}
+		_, err = q.DeleteFollowRequestNotification(ctx, db.DeleteFollowRequestNotificationParams{
+			RecipientID: viewer,
+			ActorID:     requester,
+		})
+		return err
+	})
+}
+
+// answerFollowRequest runs answer in a transaction on the request from
+// {username} to the viewer.
+func (h *Handler) answerFollowRequest(w http.ResponseWriter, r *http.Request, answer func(ctx context.Context, q db.Querier, viewer, requester uuid.UUID) error) {
+	ctx := r.Context()
+	viewer, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+	requester, ok := h.lookupUser(w, r)
+	if !ok {
+		return
+	}
+
+	err = h.store.InTx(ctx, func(q db.Querier) error {
+		return answer(ctx, q, viewer, requester.ID)
+	})
+	switch {
+	case errors.Is(err, errNoFollowRequest):
+		httpx.WriteError(w, http.StatusNotFound, "follow_request_not_found", "that user has not asked to follow you")
+	case db.IsBlocked(err):
+		// A block landed after the request was made.
+		writeBlocked(w)
+	case err != nil:
+		internalError(w, r, "failed to answer follow request", err)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,89 @@
+package users_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/testutil"
+	"github.com/hrutav-modha/social-media-app/server/internal/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivateAccountAPI(t *testing.T) {
+	s := testutil.NewServer(t)
+	ctx := context.Background()
+	alice := s.RegisterUser(t, "alice")
+	bob := s.RegisterUser(t, "bob")
+	carol := s.RegisterUser(t, "carol")
+	aliceID := uuid.MustParse(alice.ID)
+
+	resp := s.Do(t, http.MethodPatch, "/api/v1/users/me", map[string]any{"is_private": true}, alice)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var profile users.Profile
+	testutil.DecodeJSON(t, resp, &profile)
+	assert.True(t, profile.IsPrivate)
+
+	for _, u := range []*testutil.User{bob, carol} {
+		resp := s.Do(t, http.MethodPost, "/api/v1/users/alice/follow", nil, u)
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+		var body map[string]string
+		testutil.DecodeJSON(t, resp, &body)
+		assert.Equal(t, "requested", body["status"])
+	}
+
+	t.Run("Requesters see their pending request", func(t *testing.T) {
+		resp := s.Do(t, http.MethodGet, "/api/v1/users/alice", nil, bob)
+		var profile users.Profile
+		testutil.DecodeJSON(t, resp, &profile)
+		require.NotNil(t, profile.FollowRequested)
+		assert.True(t, *profile.FollowRequested)
+		assert.False(t, *profile.IsFollowing)
+	})
+
+	t.Run("The target lists and answers requests", func(t *testing.T) {
+		resp := s.Do(t, http.MethodGet, "/api/v1/users/me/follow-requests", nil, alice)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var page users.UserPage
+		testutil.DecodeJSON(t, resp, &page)
+		require.Len(t, page.Users, 2)
+		assert.Equal(t, "carol", page.Users[0].Username, "newest first")
+
+		resp = s.Do(t, http.MethodPost, "/api/v1/users/me/follow-requests/bob/approve", nil, alice)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		resp = s.Do(t, http.MethodPost, "/api/v1/users/me/follow-requests/carol/reject", nil, alice)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		following, err := s.App.Store.IsFollowing(ctx, db.IsFollowingParams{FollowerID: uuid.MustParse(bob.ID), FollowingID: aliceID})
+		require.NoError(t, err)
+		assert.True(t, following)
+		following, err = s.App.Store.IsFollowing(ctx, db.IsFollowingParams{FollowerID: uuid.MustParse(carol.ID), FollowingID: aliceID})
+		require.NoError(t, err)
+		assert.False(t, following)
+
+		notices, err := s.App.Store.ListNotifications(ctx, db.ListNotificationsParams{RecipientID: aliceID, Now: s.Clock.Now(), PageSize: 10})
+		require.NoError(t, err)
+		require.Len(t, notices, 1)
+		assert.Equal(t, "follow", notices[0].Type)
+		assert.Equal(t, uuid.MustParse(bob.ID), notices[0].ActorID)
+	})
+
+	t.Run("Going public approves pending requests", func(t *testing.T) {
+		resp := s.Do(t, http.MethodPost, "/api/v1/users/alice/follow", nil, carol)
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+		resp = s.Do(t, http.MethodPatch, "/api/v1/users/me", map[string]any{"is_private": false}, alice)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var profile users.Profile
+		testutil.DecodeJSON(t, resp, &profile)
+		assert.EqualValues(t, 2, profile.FollowerCount)
+
+		resp = s.Do(t, http.MethodGet, "/api/v1/users/me/follow-requests", nil, alice)
+		var page users.UserPage
+		testutil.DecodeJSON(t, resp, &page)
+		assert.Empty(t, page.Users)
+	})
+}
@@ -0,0 +1,135 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/httpx"
+	"github.com/jackc/pgx/v5"
+)
+
+// Profile is the public view of a user. AvatarURL and BannerURL point at
+// the largest rendition; smaller ones differ only in the size suffix. The
+// viewer-relative flags are only set when the request is authenticated and
+// the viewer is someone else. FollowRequested means the viewer is waiting
+// for this private account to approve them.
+type Profile struct {
+	ID             uuid.UUID `json:"id"`
+	Username       string    `json:"username"`
+	DisplayName    string    `json:"display_name"`
+	Bio            *string   `json:"bio"`
+	AvatarURL      *string   `json:"avatar_url"`
+	BannerURL      *string   `json:"banner_url"`
+	Location       *string   `json:"location"`
+	Pronouns       *string   `json:"pronouns"`
+	Links          []string  `json:"links"`
+	IsPrivate      bool      `json:"is_private"`
+	CreatedAt      time.Time `json:"created_at"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+	PostCount      int64     `json:"post_count"`
+
+	IsFollowing     *bool `json:"is_following,omitempty"`
+	FollowsYou      *bool `json:"follows_you,omitempty"`
+	IsBlocked       *bool `json:"is_blocked,omitempty"`
+	FollowRequested *bool `json:"follow_requested,omitempty"`
+	IsMuted         *bool `json:"is_muted,omitempty"`
+}
+
+// loadProfile looks username up case-insensitively, finding no one if there
+// is a block between them and viewer. The counts come from the user_stats
+// counters rather than being counted per request.
+func (h *Handler) loadProfile(ctx context.Context, username string, viewer uuid.UUID) (Profile, error) {
+	row, err := h.store.GetUserProfile(ctx, db.GetUserProfileParams{Username: username, ViewerID: viewer})
+	if err != nil {
+		return Profile{}, err
+	}
+	return Profile{
+		ID:             row.ID,
+		Username:       row.Username,
+		DisplayName:    row.DisplayName,
+		Bio:            row.Bio,
+		AvatarURL:      row.AvatarUrl,
+		BannerURL:      row.BannerUrl,
+		Location:       row.Location,
+		Pronouns:       row.Pronouns,
+		Links:          row.Links,
+		IsPrivate:      row.IsPrivate,
+		CreatedAt:      row.CreatedAt,
+		FollowerCount:  row.FollowerCount,
+		FollowingCount: row.FollowingCount,
+		PostCount:      row.PostCount,
+	}, nil
+}
+
+// GetProfile handles GET /api/v1/users/{username}. A username given up in a
+// recent rename redirects to its owner's current profile.
+func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// The body differs per viewer, so shared caches must key on the token.
+	w.Header().Add("Vary", "Authorization")
+
+	viewer, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+
+	username := chi.URLParam(r, "username")
+	profile, err := h.loadProfile(ctx, username, viewer)
+	if errors.Is(err, pgx.ErrNoRows) {
+		h.redirectRenamed(w, r, username, viewer)
+		return
+	}
+	if err != nil {
+		internalError(w, r, "failed to load profile", err)
+		return
+	}
+
+	if viewer != uuid.Nil && viewer != profile.ID {
+		rel, err := h.store.GetRelationship(ctx, db.GetRelationshipParams{
+			ViewerID: viewer,
+			UserID:   profile.ID,
+			Now:      h.clock.Now(),
+		})
+		if err != nil {
+			internalError(w, r, "failed to load relationship", err)
+			return
+		}
+		profile.IsFollowing = &rel.IsFollowing
+		profile.FollowsYou = &rel.FollowsYou
+		profile.IsBlocked = &rel.IsBlocked
+		profile.FollowRequested = &rel.IsRequested
+		profile.IsMuted = &rel.IsMuted
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, profile)
+}
+
+// redirectRenamed answers a lookup of a username no one holds: a redirect
+// if it was given up within UsernameRedirectPeriod, a 404 otherwise. The
+// redirect is temporary since the old name is eventually released. Owners
+// the profile would be hidden from get a 404 too, so the redirect can't
+// give their current name away.
+func (h *Handler) redirectRenamed(w http.ResponseWriter, r *http.Request, username string, viewer uuid.UUID) {
+	current, err := h.store.GetUsernameRedirect(r.Context(), db.GetUsernameRedirectParams{
+		Username: username,
+		Now:      h.clock.Now(),
+		ViewerID: viewer,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		httpx.WriteError(w, http.StatusNotFound, "user_not_found", "no user has that username")
+		return
+	}
+	if err != nil {
+		internalError(w, r, "failed to look up renamed user", err)
+		return
+	}
+	http.Redirect(w, r, path.Join(path.Dir(r.URL.Path), current), http.StatusFound)
+}
@@ -0,0 +1,176 @@
+package users
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/httpx"
+)
+
+// Limits for user search. Typeahead answers are kept short since they
+// are fetched on every keystroke.
+const (
+	MaxSearchQueryLength = 64
+	DefaultTypeaheadSize = 8
+	MaxTypeaheadSize     = 20
+)
+
+// SearchResult is a user found by search. FollowsYou, like IsFollowing, is
+// only set for authenticated requests.
+type SearchResult struct {
+	UserSummary
+	FollowsYou *bool `json:"follows_you,omitempty"`
+}
+
+// SearchPage is one page of search results, best match first. NextCursor
+// is empty on the last page.
+type SearchPage struct {
+	Users      []SearchResult `json:"users"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// searchCursor marks the last result of a page. Results are ordered by
+// rank with the ID breaking ties, so the next page is everything ranked
+// below (Rank, ID).
+type searchCursor struct {
+	Rank float64
+	ID   uuid.UUID
+}
+
+// String encodes c as an opaque token for the next_cursor field. The rank
+// is written in full so it compares equal to the one it came from.
+func (c searchCursor) String() string {
+	raw := strconv.FormatFloat(c.Rank, 'g', -1, 64) + ":" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func parseSearchCursor(s string) (searchCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return searchCursor{}, errInvalidCursor
+	}
+	rank, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return searchCursor{}, errInvalidCursor
+	}
+	var c searchCursor
+	if c.Rank, err = strconv.ParseFloat(rank, 64); err != nil {
+		return searchCursor{}, errInvalidCursor
+	}
+	if c.ID, err = uuid.Parse(id); err != nil {
+		return searchCursor{}, errInvalidCursor
+	}
+	return c, nil
+}
+
+// escapeLike makes s match itself literally in a LIKE pattern.
+var escapeLike = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace
+
+// parseSearchQuery reads ?q=, dropping a leading @ so mentions can be
+// completed as typed, and writes a 400 and returns false if nothing is
+// left or it is too long.
+func parseSearchQuery(w http.ResponseWriter, r *http.Request) (string, bool) {
+	q := strings.TrimPrefix(strings.TrimSpace(r.URL.Query().Get("q")), "@")
+	if q == "" || utf8.RuneCountInString(q) > MaxSearchQueryLength {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid_query",
+			"q must be between 1 and "+strconv.Itoa(MaxSearchQueryLength)+" characters")
+		return "", false
+	}
+	return q, true
+}
+
+// SearchUsers handles GET /api/v1/users/search?q=. Matching ignores case
+// and accents and tolerates typos; usernames starting with the query and
+// people the viewer knows rank higher. With ?mode=typeahead it instead
+// returns a short, unpaged list of prefix matches for mention
+// autocompletion.
+func (h *Handler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	// Ranking and the follow flags depend on who is asking.
+	w.Header().Add("Vary", "Authorization")
+
+	viewer, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+	q, ok := parseSearchQuery(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.URL.Query().Get("mode") {
+	case "":
+		h.search(w, r, viewer, q)
+	case "typeahead":
+		h.typeahead(w, r, viewer, q)
+	default:
+		httpx.WriteError(w, http.StatusBadRequest, "invalid_mode", "mode must be typeahead or omitted")
+	}
+}
+
+func (h *Handler) search(w http.ResponseWriter, r *http.Request, viewer uuid.UUID, q string) {
+	limit, ok := parseLimit(w, r, DefaultPageSize, MaxPageSize)
+	if !ok {
+		return
+	}
+	params := db.SearchUsersParams{Query: q, Prefix: escapeLike(q), ViewerID: viewer, PageSize: int32(limit + 1)}
+	if s := r.URL.Query().Get("cursor"); s != "" {
+		c, err := parseSearchCursor(s)
+		if err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, "invalid_cursor", "cursor is malformed")
+			return
+		}
+		params.BeforeRank, params.BeforeID = &c.Rank, &c.ID
+	}
+
+	rows, err := h.store.SearchUsers(r.Context(), params)
+	if err != nil {
+		internalError(w, r, "failed to search users", err)
+		return
+	}
+	var next string
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		next = searchCursor{Rank: last.Rank, ID: last.ID}.String()
+	}
+
+	users := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		users[i] = SearchResult{
+			UserSummary: UserSummary{
+				ID: row.ID, Username: row.Username, DisplayName: row.DisplayName, AvatarURL: row.AvatarUrl,
+				IsFollowing: followFlag(viewer, row.IsFollowing),
+			},
+			FollowsYou: followFlag(viewer, row.FollowsYou),
+		}
+	}
+	httpx.WriteJSON(w, http.StatusOK, SearchPage{Users: users, NextCursor: next})
+}
+
+func (h *Handler) typeahead(w http.ResponseWriter, r *http.Request, viewer uuid.UUID, q string) {
+	limit, ok := parseLimit(w, r, DefaultTypeaheadSize, MaxTypeaheadSize)
+	if !ok {
+		return
+	}
+	rows, err := h.store.TypeaheadUsers(r.Context(), db.TypeaheadUsersParams{
+		Prefix:   escapeLike(q),
+		ViewerID: viewer,
+		PageSize: int32(limit),
+	})
+	if err != nil {
+		internalError(w, r, "failed to complete username", err)
+		return
+	}
+
+	users := make([]UserSummary, len(rows))
+	for i, row := range rows {
+		users[i] = UserSummary{ID: row.ID, Username: row.Username, DisplayName: row.DisplayName, AvatarURL: row.AvatarUrl}
+	}
+	httpx.WriteJSON(w, http.StatusOK, UserPage{Users: users})
+}
@@ -0,0 +1,162 @@
+package users_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/middleware"
+	"github.com/hrutav-modha/social-media-app/server/internal/testutil"
+	"github.com/hrutav-modha/social-media-app/server/internal/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// searchStore returns canned results and records the last parameters it
+// was queried with.
+type searchStore struct {
+	db.Store
+	results   []db.SearchUsersRow
+	search    db.SearchUsersParams
+	typeahead db.TypeaheadUsersParams
+}
+
+func (s *searchStore) SearchUsers(_ context.Context, arg db.SearchUsersParams) ([]db.SearchUsersRow, error) {
+	s.search = arg
+	rows := s.results
+	if arg.BeforeRank != nil {
+		rows = nil
+		for _, row := range s.results {
+			if row.Rank < *arg.BeforeRank || (row.Rank == *arg.BeforeRank && row.ID.String() < arg.BeforeID.String()) {
+				rows = append(rows, row)
+			}
+		}
+	}
+	return rows[:min(len(rows), int(arg.PageSize))], nil
+}
+
+func (s *searchStore) TypeaheadUsers(_ context.Context, arg db.TypeaheadUsersParams) ([]db.TypeaheadUsersRow, error) {
+	s.typeahead = arg
+	return []db.TypeaheadUsersRow{}, nil
+}
+
+func TestSearchUsers(t *testing.T) {
+	store := &searchStore{}
+	for i, rank := range []float64{2.75, 1.1 / 3, 1.1 / 3, 0.3} {
+		store.results = append(store.results, db.SearchUsersRow{
+			ID:       uuid.UUID{15: byte(9 - i)},
+			Username: "user" + string(rune('a'+i)),
+			Rank:     rank,
+		})
+	}
+	h := users.NewHandler(store, testutil.NewClock(testutil.Epoch), users.Media{}, nil, nil)
+	_, tokens := newRouter(t, h)
+	r := chi.NewRouter()
+	r.With(middleware.OptionalAuth(tokens)).Get("/users/search", h.SearchUsers)
+	get := func(query url.Values) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/search?"+query.Encode(), nil))
+		return w
+	}
+
+	t.Run("Queries are validated", func(t *testing.T) {
+		for _, q := range []url.Values{
+			{"q": {""}},
+			{"q": {"@"}},
+			{"q": {string(make([]byte, users.MaxSearchQueryLength+1))}},
+			{"q": {"ann"}, "mode": {"fuzzy"}},
+			{"q": {"ann"}, "cursor": {"nope"}},
+			{"q": {"ann"}, "mode": {"typeahead"}, "limit": {"21"}},
+		} {
+			assert.Equal(t, http.StatusBadRequest, get(q).Code, q.Encode())
+		}
+	})
+
+	t.Run("Wildcards are matched literally", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, get(url.Values{"q": {" @an%n_\\ "}}).Code)
+		assert.Equal(t, "an%n_\\", store.search.Query)
+		assert.Equal(t, `an\%n\_\\`, store.search.Prefix)
+
+		require.Equal(t, http.StatusOK, get(url.Values{"q": {"@jo_"}, "mode": {"typeahead"}}).Code)
+		assert.Equal(t, `jo\_`, store.typeahead.Prefix)
+		assert.EqualValues(t, users.DefaultTypeaheadSize, store.typeahead.PageSize)
+	})
+
+	t.Run("Pages resume after equally ranked results", func(t *testing.T) {
+		var names []string
+		query := url.Values{"q": {"user"}, "limit": {"2"}}
+		for pages := 0; pages < 3; pages++ {
+			w := get(query)
+			require.Equal(t, http.StatusOK, w.Code)
+			var page users.SearchPage
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+			for _, u := range page.Users {
+				names = append(names, u.Username)
+				assert.Nil(t, u.IsFollowing, "anonymous viewers follow no one")
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			query.Set("cursor", page.NextCursor)
+		}
+		assert.Equal(t, []string{"usera", "userb", "userc", "userd"}, names)
+	})
+}
+
+func TestSearchAPI(t *testing.T) {
+	s := testutil.NewServer(t)
+	ctx := context.Background()
+	viewer := s.RegisterUser(t, "viewer")
+	for _, name := range []string{"josefine", "joseph", "josh"} {
+		s.RegisterUser(t, name)
+	}
+	mallory := s.RegisterUser(t, "mallory")
+	friend := s.RegisterUser(t, "josemaria")
+	_, err := s.App.Store.UpdateUserProfile(ctx, db.UpdateUserProfileParams{
+		ID: uuid.MustParse(friend.ID), DisplayName: "José María", Links: []string{},
+	})
+	require.NoError(t, err)
+
+	search := func(query url.Values, as *testutil.User) []string {
+		t.Helper()
+		resp := s.Do(t, http.MethodGet, "/api/v1/users/search?"+query.Encode(), nil, as)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var page users.SearchPage
+		testutil.DecodeJSON(t, resp, &page)
+		var names []string
+		for _, u := range page.Users {
+			names = append(names, u.Username)
+		}
+		return names
+	}
+
+	t.Run("Matching folds case and accents and tolerates typos", func(t *testing.T) {
+		assert.Contains(t, search(url.Values{"q": {"JOSÉ MARIA"}}, nil), "josemaria")
+		assert.Contains(t, search(url.Values{"q": {"josphine"}}, nil), "josefine")
+		assert.NotContains(t, search(url.Values{"q": {"jose"}}, nil), "mallory")
+	})
+
+	t.Run("Accounts the viewer follows rank first", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, s.Do(t, http.MethodPost, "/api/v1/users/josemaria/follow", nil, viewer).StatusCode)
+		got := search(url.Values{"q": {"jose"}}, viewer)
+		require.NotEmpty(t, got)
+		assert.Equal(t, "josemaria", got[0])
+
+		got = search(url.Values{"q": {"jo"}, "mode": {"typeahead"}}, viewer)
+		require.NotEmpty(t, got)
+		assert.Equal(t, "josemaria", got[0])
+		assert.Contains(t, got, "josh")
+	})
+
+	t.Run("Blocked accounts can't find each other", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, s.Do(t, http.MethodPost, "/api/v1/users/mallory/block", nil, viewer).StatusCode)
+		assert.NotContains(t, search(url.Values{"q": {"mallory"}}, viewer), "mallory")
+		assert.NotContains(t, search(url.Values{"q": {"viewer"}, "mode": {"typeahead"}}, mallory), "viewer")
+	})
+}
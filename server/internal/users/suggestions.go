@@ -0,0 +1,328 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/auth"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/httpx"
+	"github.com/hrutav-modha/social-media-app/server/internal/logging"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// SuggestionsPerUser is how many suggestions are precomputed for each
+	// user, and the most one request returns.
+	SuggestionsPerUser = 50
+	// SuggestionsTTL is how long a precomputed list is served. Refreshes
+	// normally replace it well before then.
+	SuggestionsTTL = 24 * time.Hour
+	// SuggestionsActiveWindow is how recently a user must have asked for
+	// suggestions for the periodic refresh to keep theirs up to date.
+	SuggestionsActiveWindow = 7 * 24 * time.Hour
+	// DismissedSuggestionTTL is how long a dismissed account stays hidden.
+	DismissedSuggestionTTL = 90 * 24 * time.Hour
+
+	defaultSuggestions = 20
+
+	// Inputs to the ranking query: how many of the most-followed accounts
+	// are considered for everyone, and how far back posts count as recent.
+	suggestionPopularPool    = 200
+	suggestionActivityWindow = 7 * 24 * time.Hour
+
+	suggestionRefreshBatch   = 100
+	suggestionRefreshLockTTL = 10 * time.Minute
+)
+
+// Redis keys. Lists and dismissals are per user; the active set and the
+// lock are shared by every server.
+const (
+	SuggestionsPrefix         = "suggestions:"
+	DismissedSuggestionPrefix = "suggestions_dismissed:"
+	SuggestionsActiveKey      = "suggestions_active"
+	suggestionsLockKey        = "suggestions_refresh_lock"
+)
+
+// Suggestions precomputes who each user might want to follow and keeps
+// the lists in Redis, so serving them costs a key lookup rather than a
+// walk of the follow graph.
+type Suggestions struct {
+	store db.Store
+	rdb   *redis.Client
+	clock auth.Clock
+}
+
+// NewSuggestions returns a Suggestions ranking from store and caching in rdb.
+func NewSuggestions(store db.Store, rdb *redis.Client, clock auth.Clock) *Suggestions {
+	return &Suggestions{store: store, rdb: rdb, clock: clock}
+}
+
+// suggestion is an entry of a precomputed list.
+type suggestion struct {
+	ID          uuid.UUID `json:"id"`
+	MutualCount int64     `json:"mutual_count"`
+}
+
+// Refresh recomputes userID's suggestions and stores them.
+func (s *Suggestions) Refresh(ctx context.Context, userID uuid.UUID) ([]suggestion, error) {
+	now := s.clock.Now()
+	rows, err := s.store.ListSuggestionCandidates(ctx, db.ListSuggestionCandidatesParams{
+		UserID:      userID,
+		PopularPool: suggestionPopularPool,
+		ActiveSince: now.Add(-suggestionActivityWindow),
+		Now:         now,
+		PageSize:    SuggestionsPerUser,
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := make([]suggestion, len(rows))
+	for i, row := range rows {
+		list[i] = suggestion{ID: row.ID, MutualCount: row.MutualCount}
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.rdb.Set(ctx, SuggestionsPrefix+userID.String(), data, SuggestionsTTL).Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// list returns userID's precomputed suggestions minus the dismissed ones,
+// computing them first if there are none, and marks userID active so the
+// periodic refresh keeps them current.
+func (s *Suggestions) list(ctx context.Context, userID uuid.UUID) ([]suggestion, error) {
+	member := userID.String()
+	err := s.rdb.ZAdd(ctx, SuggestionsActiveKey, redis.Z{Score: float64(s.clock.Now().Unix()), Member: member}).Err()
+	if err != nil {
+		return nil, err
+	}
+
+	var list []suggestion
+	data, err := s.rdb.Get(ctx, SuggestionsPrefix+member).Bytes()
+	switch {
+	case errors.Is(err, redis.Nil):
+		if list, err = s.Refresh(ctx, userID); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+	}
+
+	dismissed, err := s.rdb.SMembersMap(ctx, DismissedSuggestionPrefix+member).Result()
+	if err != nil {
+		return nil, err
+	}
+	kept := list[:0]
+	for _, sg := range list {
+		if _, ok := dismissed[sg.ID.String()]; !ok {
+			kept = append(kept, sg)
+		}
+	}
+	return kept, nil
+}
+
+// Dismiss stops suggesting target to userID for DismissedSuggestionTTL.
+func (s *Suggestions) Dismiss(ctx context.Context, userID, target uuid.UUID) error {
+	key := DismissedSuggestionPrefix + userID.String()
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, key, target.String())
+		pipe.Expire(ctx, key, DismissedSuggestionTTL)
+		return nil
+	})
+	return err
+}
+
+// releaseLock deletes a lock only if it still holds the caller's token, so
+// a server whose lock expired mid-run can't release the next holder's.
+var releaseLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// extendLock renews a lock's TTL, in milliseconds, only if it still holds
+// the caller's token. It returns 0 once the lock has gone to someone else.
+var extendLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// RefreshActive recomputes the suggestions of every user who asked for
+// them within SuggestionsActiveWindow and forgets the rest. Only one server
+// refreshes at a time; the others return straight away, and a server that
+// loses the lock mid-run stops. It returns how many users were refreshed.
+func (s *Suggestions) RefreshActive(ctx context.Context) (int, error) {
+	token := uuid.NewString()
+	ok, err := s.rdb.SetNX(ctx, suggestionsLockKey, token, suggestionRefreshLockTTL).Result()
+	if err != nil || !ok {
+		return 0, err
+	}
+	defer releaseLock.Run(context.WithoutCancel(ctx), s.rdb, []string{suggestionsLockKey}, token)
+
+	cutoff := s.clock.Now().Add(-SuggestionsActiveWindow).Unix()
+	if err := s.rdb.ZRemRangeByScore(ctx, SuggestionsActiveKey, "-inf", "("+strconv.FormatInt(cutoff, 10)).Err(); err != nil {
+		return 0, err
+	}
+
+	// Users keep joining and being rescored while this runs, which would
+	// shift offset-based pages. ZSCAN returns everyone who stays in the set
+	// for the whole scan, at worst more than once.
+	refreshed := 0
+	var errs []error
+	var cursor uint64
+	for {
+		var pairs []string
+		pairs, cursor, err = s.rdb.ZScan(ctx, SuggestionsActiveKey, cursor, "", suggestionRefreshBatch).Result()
+		if err != nil {
+			return refreshed, err
+		}
+		// ZSCAN alternates members with their scores.
+		for i := 0; i < len(pairs); i += 2 {
+			held, err := extendLock.Run(ctx, s.rdb, []string{suggestionsLockKey}, token, suggestionRefreshLockTTL.Milliseconds()).Int()
+			if err != nil {
+				return refreshed, err
+			}
+			if held == 0 {
+				return refreshed, errors.Join(errs...)
+			}
+
+			id, err := uuid.Parse(pairs[i])
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if _, err := s.Refresh(ctx, id); err != nil {
+				if ctx.Err() != nil {
+					return refreshed, ctx.Err()
+				}
+				errs = append(errs, err)
+				continue
+			}
+			refreshed++
+		}
+		if cursor == 0 {
+			return refreshed, errors.Join(errs...)
+		}
+	}
+}
+
+// Run calls RefreshActive every interval until ctx is done.
+func (s *Suggestions) Run(ctx context.Context, interval time.Duration) {
+	log := logging.FromContext(ctx).With(logging.ModuleKey, "users")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		start := s.clock.Now()
+		n, err := s.RefreshActive(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error("failed to refresh suggestions", slog.Int("refreshed", n), slog.Any("error", err))
+			continue
+		}
+		log.Info("refreshed suggestions", slog.Int("refreshed", n), slog.Duration("took", s.clock.Now().Sub(start)))
+	}
+}
+
+// SuggestedUser is an account suggested to the viewer. MutualCount is how
+// many people the viewer follows follow them, for "followed by N people
+// you follow".
+type SuggestedUser struct {
+	UserSummary
+	MutualCount int64 `json:"mutual_count"`
+}
+
+// SuggestionsResponse is the body of GET /api/v1/users/suggestions.
+type SuggestionsResponse struct {
+	Users []SuggestedUser `json:"users"`
+}
+
+// ListSuggestions handles GET /api/v1/users/suggestions. The precomputed
+// list is checked against the current graph, so someone followed, blocked
+// or muted since the last refresh drops out straight away.
+func (h *Handler) ListSuggestions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	viewer, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+	limit, ok := parseLimit(w, r, defaultSuggestions, SuggestionsPerUser)
+	if !ok {
+		return
+	}
+
+	list, err := h.suggestions.list(ctx, viewer)
+	if err != nil {
+		internalError(w, r, "failed to load suggestions", err)
+		return
+	}
+	ids := make([]uuid.UUID, len(list))
+	for i, sg := range list {
+		ids[i] = sg.ID
+	}
+	rows, err := h.store.GetSuggestedUsers(ctx, db.GetSuggestedUsersParams{Ids: ids, ViewerID: viewer, Now: h.clock.Now()})
+	if err != nil {
+		internalError(w, r, "failed to load suggested users", err)
+		return
+	}
+	byID := make(map[uuid.UUID]db.GetSuggestedUsersRow, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+
+	users := []SuggestedUser{}
+	for _, sg := range list {
+		row, ok := byID[sg.ID]
+		if !ok {
+			continue
+		}
+		users = append(users, SuggestedUser{
+			UserSummary: UserSummary{ID: row.ID, Username: row.Username, DisplayName: row.DisplayName, AvatarURL: row.AvatarUrl},
+			MutualCount: sg.MutualCount,
+		})
+		if len(users) == limit {
+			break
+		}
+	}
+	httpx.WriteJSON(w, http.StatusOK, SuggestionsResponse{Users: users})
+}
+
+// DismissSuggestion handles DELETE /api/v1/users/suggestions/{username}.
+func (h *Handler) DismissSuggestion(w http.ResponseWriter, r *http.Request) {
+	viewer, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+	target, ok := h.lookupUser(w, r)
+	if !ok {
+		return
+	}
+	if err := h.suggestions.Dismiss(r.Context(), viewer, target.ID); err != nil {
+		internalError(w, r, "failed to dismiss suggestion", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,242 @@
+package users_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/middleware"
+	"github.com/hrutav-modha/social-media-app/server/internal/testutil"
+	"github.com/hrutav-modha/social-media-app/server/internal/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// suggestionStore ranks a fixed list of candidates and counts how often it
+// is asked to, so tests can tell cached lists from recomputed ones.
+type suggestionStore struct {
+	db.Store
+	users      map[string]db.User
+	candidates []db.ListSuggestionCandidatesRow
+	hidden     map[uuid.UUID]bool
+	ranked     int
+	onRank     func()
+}
+
+func (s *suggestionStore) ListSuggestionCandidates(_ context.Context, arg db.ListSuggestionCandidatesParams) ([]db.ListSuggestionCandidatesRow, error) {
+	s.ranked++
+	if s.onRank != nil {
+		s.onRank()
+	}
+	return s.candidates[:min(len(s.candidates), int(arg.PageSize))], nil
+}
+
+func (s *suggestionStore) GetSuggestedUsers(_ context.Context, arg db.GetSuggestedUsersParams) ([]db.GetSuggestedUsersRow, error) {
+	var rows []db.GetSuggestedUsersRow
+	for _, u := range s.users {
+		for _, id := range arg.Ids {
+			if u.ID == id && !s.hidden[id] {
+				rows = append(rows, db.GetSuggestedUsersRow{ID: u.ID, Username: u.Username, DisplayName: u.DisplayName})
+			}
+		}
+	}
+	return rows, nil
+}
+
+func (s *suggestionStore) GetUserByUsername(_ context.Context, username string) (db.User, error) {
+	return s.users[username], nil
+}
+
+func TestSuggestions(t *testing.T) {
+	ctx := context.Background()
+	viewer := uuid.New()
+	store := &suggestionStore{users: map[string]db.User{}, hidden: map[uuid.UUID]bool{}}
+	for i, name := range []string{"dave", "erin", "frank"} {
+		u := db.User{ID: uuid.New(), Username: name, DisplayName: name}
+		store.users[name] = u
+		store.candidates = append(store.candidates, db.ListSuggestionCandidatesRow{ID: u.ID, MutualCount: int64(3 - i)})
+	}
+
+	rdb, mr := testutil.Redis(t)
+	clock := testutil.NewClock(testutil.Epoch)
+	clock.Follow(mr)
+	suggestions := users.NewSuggestions(store, rdb, clock)
+	h := users.NewHandler(store, clock, users.Media{}, suggestions, nil)
+
+	_, tokens := newRouter(t, h)
+	r := chi.NewRouter()
+	r.Use(middleware.Auth(tokens))
+	r.Get("/users/suggestions", h.ListSuggestions)
+	r.Delete("/users/suggestions/{username}", h.DismissSuggestion)
+	token, err := tokens.GenerateAccessToken(viewer.String())
+	require.NoError(t, err)
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	list := func(path string) []string {
+		t.Helper()
+		w := do(http.MethodGet, path)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp users.SuggestionsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		names := []string{}
+		for _, u := range resp.Users {
+			names = append(names, u.Username)
+		}
+		return names
+	}
+
+	t.Run("Lists are computed once and then served from Redis", func(t *testing.T) {
+		assert.Equal(t, []string{"dave", "erin", "frank"}, list("/users/suggestions"))
+		assert.Equal(t, []string{"dave", "erin"}, list("/users/suggestions?limit=2"))
+		assert.Equal(t, 1, store.ranked)
+		assert.True(t, mr.Exists(users.SuggestionsPrefix+viewer.String()))
+
+		w := do(http.MethodGet, "/users/suggestions?limit=51")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Accounts the viewer can no longer see drop out", func(t *testing.T) {
+		store.hidden[store.users["erin"].ID] = true
+		defer delete(store.hidden, store.users["erin"].ID)
+		assert.Equal(t, []string{"dave", "frank"}, list("/users/suggestions"))
+	})
+
+	t.Run("Dismissed accounts stay hidden across refreshes", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/users/suggestions/dave").Code)
+		assert.Equal(t, []string{"erin", "frank"}, list("/users/suggestions"))
+
+		_, err := suggestions.Refresh(ctx, viewer)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"erin", "frank"}, list("/users/suggestions"))
+
+		clock.Advance(users.DismissedSuggestionTTL)
+		_, err = suggestions.Refresh(ctx, viewer)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"dave", "erin", "frank"}, list("/users/suggestions"))
+	})
+
+	t.Run("Only recently active users are refreshed", func(t *testing.T) {
+		store.ranked = 0
+		n, err := suggestions.RefreshActive(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, 1, store.ranked)
+
+		mr.Set("suggestions_refresh_lock", "1")
+		n, err = suggestions.RefreshActive(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n, "another server holds the lock")
+		mr.Del("suggestions_refresh_lock")
+
+		// The lock expires mid-run and another server takes it over.
+		store.onRank = func() { mr.Set("suggestions_refresh_lock", "other") }
+		n, err = suggestions.RefreshActive(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		lock, err := mr.Get("suggestions_refresh_lock")
+		require.NoError(t, err)
+		assert.Equal(t, "other", lock, "a lost lock is left to its new holder")
+		mr.Del("suggestions_refresh_lock")
+		store.onRank = nil
+
+		n, err = suggestions.RefreshActive(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.False(t, mr.Exists("suggestions_refresh_lock"), "the lock is released after a run")
+
+		clock.Advance(users.SuggestionsActiveWindow + time.Second)
+		n, err = suggestions.RefreshActive(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		assert.False(t, mr.Exists(users.SuggestionsActiveKey))
+	})
+
+	t.Run("Long runs keep the lock", func(t *testing.T) {
+		for range 3 {
+			_, err := mr.ZAdd(users.SuggestionsActiveKey, float64(clock.Now().Unix()), uuid.NewString())
+			require.NoError(t, err)
+		}
+		held := 0
+		store.onRank = func() {
+			if mr.Exists("suggestions_refresh_lock") {
+				held++
+			}
+			clock.Advance(6 * time.Minute)
+		}
+		defer func() { store.onRank = nil }()
+
+		n, err := suggestions.RefreshActive(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+		assert.Equal(t, 3, held, "each user renews the lock past its TTL")
+	})
+}
+
+func TestSuggestionsAPI(t *testing.T) {
+	s := testutil.NewServer(t)
+	ctx := context.Background()
+	alice := s.RegisterUser(t, "alice")
+	bob := s.RegisterUser(t, "bob")
+	carol := s.RegisterUser(t, "carol")
+	dave := s.RegisterUser(t, "dave")
+	s.RegisterUser(t, "erin")
+	s.RegisterUser(t, "frank")
+
+	follow := func(u *testutil.User, username string) {
+		t.Helper()
+		resp := s.Do(t, http.MethodPost, "/api/v1/users/"+username+"/follow", nil, u)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	}
+	// alice follows bob and carol; both follow dave, only carol follows erin.
+	follow(alice, "bob")
+	follow(alice, "carol")
+	follow(bob, "dave")
+	follow(carol, "dave")
+	follow(carol, "erin")
+
+	list := func() []users.SuggestedUser {
+		t.Helper()
+		_, err := s.App.Suggestions.Refresh(ctx, uuid.MustParse(alice.ID))
+		require.NoError(t, err)
+		resp := s.Do(t, http.MethodGet, "/api/v1/users/suggestions", nil, alice)
+		var body users.SuggestionsResponse
+		testutil.DecodeJSON(t, resp, &body)
+		return body.Users
+	}
+	names := func(list []users.SuggestedUser) []string {
+		var out []string
+		for _, u := range list {
+			out = append(out, u.Username)
+		}
+		return out
+	}
+
+	t.Run("Friends of friends rank first", func(t *testing.T) {
+		got := list()
+		require.GreaterOrEqual(t, len(got), 2)
+		assert.Equal(t, "dave", got[0].Username)
+		assert.EqualValues(t, 2, got[0].MutualCount)
+		assert.Equal(t, "erin", got[1].Username)
+		assert.NotContains(t, names(got), "bob", "already followed")
+		assert.NotContains(t, names(got), "alice")
+	})
+
+	t.Run("Blocked and muted accounts are never suggested", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, s.Do(t, http.MethodPost, "/api/v1/users/alice/block", nil, dave).StatusCode)
+		require.Equal(t, http.StatusNoContent, s.Do(t, http.MethodPost, "/api/v1/users/erin/mute", nil, alice).StatusCode)
+		got := names(list())
+		assert.NotContains(t, got, "dave")
+		assert.NotContains(t, got, "erin")
+	})
+}
@@ -0,0 +1,120 @@
+package users_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/server"
+	"github.com/hrutav-modha/social-media-app/server/internal/testutil"
+	"github.com/hrutav-modha/social-media-app/server/internal/users"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// multipartFile wraps data as the "file" field of a multipart body.
+func multipartFile(t *testing.T, data []byte) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "upload.jpg")
+	require.NoError(t, err)
+	_, err = fw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+	return &body, mw.FormDataContentType()
+}
+
+func pngBytes(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))))
+	return buf.Bytes()
+}
+
+func TestUploadAvatar(t *testing.T) {
+	ctx := context.Background()
+	s3, endpoint := testutil.S3(t)
+	media := users.Media{Client: s3, Bucket: server.MediaBucket, BaseURL: "http://" + endpoint + "/media"}
+
+	alice := uuid.New()
+	store := &fakeStore{avatars: map[uuid.UUID]*string{alice: nil}}
+	router, tokens := newRouter(t, users.NewHandler(store, testutil.NewClock(testutil.Epoch), media, nil, nil))
+	token, err := tokens.GenerateAccessToken(alice.String())
+	require.NoError(t, err)
+
+	upload := func(data []byte) *httptest.ResponseRecorder {
+		body, contentType := multipartFile(t, data)
+		req := httptest.NewRequest(http.MethodPost, "/users/me/avatar", body)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	objects := func() []string {
+		var keys []string
+		for obj := range s3.ListObjects(ctx, server.MediaBucket, minio.ListObjectsOptions{Prefix: "avatars/", Recursive: true}) {
+			require.NoError(t, obj.Err)
+			keys = append(keys, obj.Key)
+		}
+		return keys
+	}
+
+	var first struct {
+		URL        string            `json:"url"`
+		Renditions map[string]string `json:"renditions"`
+	}
+	t.Run("Uploads are stored as renditions", func(t *testing.T) {
+		w := upload(pngBytes(t, 640, 480))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+
+		assert.Equal(t, first.URL, *store.avatars[alice])
+		assert.Len(t, first.Renditions, 3)
+		prefix := fmt.Sprintf("http://%s/media/avatars/%s/avatar-", endpoint, alice)
+		assert.True(t, strings.HasPrefix(first.URL, prefix), first.URL)
+		assert.True(t, strings.HasSuffix(first.URL, "-400.jpg"), first.URL)
+
+		keys := objects()
+		assert.Len(t, keys, 3)
+		info, err := s3.StatObject(ctx, server.MediaBucket, keys[0], minio.StatObjectOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "image/jpeg", info.ContentType)
+	})
+
+	t.Run("Replacing an avatar removes the old files", func(t *testing.T) {
+		w := upload(pngBytes(t, 100, 100))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		keys := objects()
+		assert.Len(t, keys, 3)
+		for _, k := range keys {
+			assert.NotContains(t, first.URL, strings.TrimSuffix(k, "400.jpg"))
+		}
+	})
+
+	t.Run("Content is sniffed, not trusted", func(t *testing.T) {
+		w := upload([]byte("GIF89a"))
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+		w = upload([]byte("<html><script>alert(1)</script></html>"))
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Len(t, objects(), 3)
+	})
+
+	t.Run("Oversized uploads are refused", func(t *testing.T) {
+		w := upload(make([]byte, users.MaxImageBytes+1))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
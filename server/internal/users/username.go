@@ -0,0 +1,205 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/httpx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// UsernameChangeCooldown is how long a user must wait between renames.
+	UsernameChangeCooldown = 30 * 24 * time.Hour
+
+	// UsernameRedirectPeriod is how long an old username keeps redirecting
+	// to its owner, during which nobody else can claim it.
+	UsernameRedirectPeriod = 90 * 24 * time.Hour
+)
+
+var (
+	errInvalidUsername    = errors.New("usernames must be 3-20 letters or digits")
+	errUsernameNotAllowed = errors.New("that username is not available")
+	errUsernameTaken      = errors.New("that username is taken")
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9]{3,20}$`)
+
+// reservedUsernames can't be claimed because they name routes, roles or
+// the service itself. They are compared after fold, so "adm1n" is caught.
+var reservedUsernames = []string{
+	"about", "account", "admin", "administrator", "api", "app", "auth",
+	"blog", "dev", "everyone", "explore", "feed", "help", "here", "home",
+	"login", "logout", "mail", "me", "mod", "moderator", "news", "notifications",
+	"null", "official", "register", "root", "search", "security", "settings",
+	"signin", "signup", "staff", "status", "suggestions", "support", "system",
+	"undefined", "user", "users", "www",
+}
+
+// offensiveTerms are rejected anywhere inside a folded username. Substring
+// matching has false positives (ask anyone from Scunthorpe), so keep the
+// list to terms that rarely occur inside innocent words.
+var offensiveTerms = []string{
+	"bitch", "cunt", "fag", "fuck", "hitler", "nazi", "nigg", "retard",
+	"shit", "slut", "whore",
+}
+
+// foldReplacer undoes the digit and letter swaps people use to sneak a word
+// past a list: 4dm1n, n4zi, vvhore.
+var foldReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "l", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b",
+	"rn", "m", "vv", "w",
+)
+
+func fold(s string) string {
+	return foldReplacer.Replace(strings.ToLower(s))
+}
+
+// ValidateUsername normalises a requested username and checks it may be
+// claimed. Unicode compatibility forms are folded first, so a fullwidth
+// "ａｌｉｃｅ" becomes "alice", and anything still outside ASCII letters and
+// digits is rejected; lookalikes from other scripts never get that far.
+// Whether the name is free is for the database to decide.
+func ValidateUsername(s string) (string, error) {
+	s = strings.TrimPrefix(norm.NFKC.String(strings.TrimSpace(s)), "@")
+	if !usernamePattern.MatchString(s) {
+		return "", errInvalidUsername
+	}
+
+	folded := fold(s)
+	for _, r := range reservedUsernames {
+		if folded == fold(r) {
+			return "", errUsernameNotAllowed
+		}
+	}
+	for _, t := range offensiveTerms {
+		if strings.Contains(folded, fold(t)) {
+			return "", errUsernameNotAllowed
+		}
+	}
+	return s, nil
+}
+
+// cooldownError is returned when a user renames again too soon.
+type cooldownError struct {
+	until time.Time
+}
+
+func (e *cooldownError) Error() string {
+	return fmt.Sprintf("usernames can be changed once every %d days; try again after %s",
+		int(UsernameChangeCooldown/(24*time.Hour)), e.until.UTC().Format(time.RFC3339))
+}
+
+type changeUsernameRequest struct {
+	Username string `json:"username"`
+}
+
+// ChangeUsername handles PATCH /api/v1/users/me/username. The old name is
+// kept in username_history so links to it redirect for
+// UsernameRedirectPeriod; the database rejects any name that matches
+// another user's case-insensitively or by lookalike characters.
+func (h *Handler) ChangeUsername(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := viewerID(r)
+	if err != nil {
+		writeInvalidToken(w)
+		return
+	}
+
+	var req changeUsernameRequest
+	if !httpx.DecodeJSON(w, r, &req) {
+		return
+	}
+	username, err := ValidateUsername(req.Username)
+	if errors.Is(err, errInvalidUsername) {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid_username", err.Error())
+		return
+	}
+	if err != nil {
+		httpx.WriteError(w, http.StatusUnprocessableEntity, "username_not_allowed", err.Error())
+		return
+	}
+
+	now := h.clock.Now()
+	err = h.store.InTx(ctx, func(q db.Querier) error {
+		return renameUser(ctx, q, userID, username, now)
+	})
+
+	var cooldown *cooldownError
+	switch {
+	case errors.As(err, &cooldown):
+		w.Header().Set("Retry-After", strconv.Itoa(int(cooldown.until.Sub(now).Seconds())+1))
+		httpx.WriteError(w, http.StatusTooManyRequests, "username_change_cooldown", err.Error())
+		return
+	case errors.Is(err, errUsernameTaken):
+		httpx.WriteError(w, http.StatusConflict, "username_taken", err.Error())
+		return
+	case errors.Is(err, pgx.ErrNoRows):
+		httpx.WriteError(w, http.StatusNotFound, "user_not_found", "your account no longer exists")
+		return
+	case err != nil:
+		internalError(w, r, "failed to change username", err)
+		return
+	}
+
+	profile, err := h.loadProfile(ctx, username, userID)
+	if err != nil {
+		internalError(w, r, "failed to load profile", err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, profile)
+}
+
+// renameUser gives userID the new username and records the old one.
+// Renaming to exactly the current name is a no-op; a change of case is a
+// rename like any other.
+func renameUser(ctx context.Context, q db.Querier, userID uuid.UUID, username string, now time.Time) error {
+	current, err := q.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if current.Username == username {
+		return nil
+	}
+
+	last, err := q.LastUsernameChange(ctx, userID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return err
+	case now.Before(last.Add(UsernameChangeCooldown)):
+		return &cooldownError{until: last.Add(UsernameChangeCooldown)}
+	}
+
+	held, err := q.IsUsernameHeld(ctx, db.IsUsernameHeldParams{Username: username, Now: now, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if held {
+		return errUsernameTaken
+	}
+
+	if _, err := q.UpdateUsername(ctx, db.UpdateUsernameParams{ID: userID, Username: username}); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return errUsernameTaken
+		}
+		return err
+	}
+	return q.CreateUsernameHistory(ctx, db.CreateUsernameHistoryParams{
+		UserID:     userID,
+		Username:   current.Username,
+		ChangedAt:  now,
+		ReleasedAt: now.Add(UsernameRedirectPeriod),
+	})
+}
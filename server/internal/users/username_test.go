@@ -0,0 +1,113 @@
+package users_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hrutav-modha/social-media-app/server/internal/testutil"
+	"github.com/hrutav-modha/social-media-app/server/internal/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"alice", "alice", true},
+		{"  Alice99 ", "Alice99", true},
+		{"@bob", "bob", true},
+		{"ａｌｉｃｅ", "alice", true},
+		{"ab", "", false},
+		{"al ice", "", false},
+		{"al_ice", "", false},
+		{"аlice", "", false}, // Cyrillic а
+		{"abcdefghijklmnopqrstu", "", false},
+		{"admin", "", false},
+		{"Adm1n", "", false},
+		{"me", "", false},
+		{"settings", "", false},
+		{"xxfuckxx", "", false},
+		{"5h1t", "", false},
+		{"classic", "classic", true},
+	}
+	for _, tt := range tests {
+		got, err := users.ValidateUsername(tt.in)
+		if !tt.ok {
+			assert.Error(t, err, "%q", tt.in)
+			continue
+		}
+		if assert.NoError(t, err, "%q", tt.in) {
+			assert.Equal(t, tt.want, got)
+		}
+	}
+}
+
+func TestChangeUsernameAPI(t *testing.T) {
+	s := testutil.NewServer(t)
+	alice := s.RegisterUser(t, "alice")
+	bob := s.RegisterUser(t, "bob")
+	s.RegisterUser(t, "carol")
+
+	rename := func(u *testutil.User, username string) *http.Response {
+		return s.Do(t, http.MethodPatch, "/api/v1/users/me/username", map[string]string{"username": username}, u)
+	}
+	errorCode := func(resp *http.Response) string {
+		var body map[string]string
+		testutil.DecodeJSON(t, resp, &body)
+		return body["error"]
+	}
+
+	t.Run("Invalid and reserved names are rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, rename(alice, "a").StatusCode)
+		assert.Equal(t, http.StatusUnprocessableEntity, rename(alice, "admin").StatusCode)
+	})
+
+	t.Run("Names are unique ignoring case and lookalikes", func(t *testing.T) {
+		resp := rename(alice, "BOB")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		assert.Equal(t, "username_taken", errorCode(resp))
+
+		resp = rename(alice, "b0b")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		resp = rename(alice, "caroI")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Rename redirects the old name", func(t *testing.T) {
+		resp := rename(alice, "alicia")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var profile users.Profile
+		testutil.DecodeJSON(t, resp, &profile)
+		assert.Equal(t, "alicia", profile.Username)
+
+		client := *s.Client()
+		client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+		resp, err := client.Get(s.URL + "/api/v1/users/alice")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "/api/v1/users/alicia", resp.Header.Get("Location"))
+
+		resp = s.Do(t, http.MethodGet, "/api/v1/users/ALICIA", nil, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Renames are rate limited", func(t *testing.T) {
+		resp := rename(alice, "alison")
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+	})
+
+	t.Run("Old names are held until released", func(t *testing.T) {
+		resp := rename(bob, "Alice")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		s.Clock.Advance(users.UsernameRedirectPeriod + time.Hour)
+		s.Login(t, bob)
+		resp = rename(bob, "alice")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
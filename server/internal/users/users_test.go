@@ -0,0 +1,276 @@
+package users_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/hrutav-modha/social-media-app/server/internal/auth"
+	"github.com/hrutav-modha/social-media-app/server/internal/db"
+	"github.com/hrutav-modha/social-media-app/server/internal/middleware"
+	"github.com/hrutav-modha/social-media-app/server/internal/testutil"
+	"github.com/hrutav-modha/social-media-app/server/internal/users"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) { os.Exit(testutil.Main(m)) }
+
+// fakeStore serves the queries a test needs from memory. Any other method
+// panics through the nil Querier.
+type fakeStore struct {
+	db.Querier
+	profiles      map[string]db.GetUserProfileRow
+	blocked       map[[2]uuid.UUID]bool
+	relationships map[[2]uuid.UUID]db.GetRelationshipRow
+	redirects     map[string]string
+	avatars       map[uuid.UUID]*string
+}
+
+func (f *fakeStore) InTx(ctx context.Context, fn func(db.Querier) error) error {
+	return fn(f)
+}
+
+func (f *fakeStore) GetUserProfile(ctx context.Context, arg db.GetUserProfileParams) (db.GetUserProfileRow, error) {
+	p, ok := f.profiles[arg.Username]
+	if !ok || f.blocked[[2]uuid.UUID{p.ID, arg.ViewerID}] {
+		return p, pgx.ErrNoRows
+	}
+	return p, nil
+}
+
+func (f *fakeStore) GetUsernameRedirect(ctx context.Context, arg db.GetUsernameRedirectParams) (string, error) {
+	current, ok := f.redirects[arg.Username]
+	if !ok {
+		return "", pgx.ErrNoRows
+	}
+	if _, err := f.GetUserProfile(ctx, db.GetUserProfileParams{Username: current, ViewerID: arg.ViewerID}); err != nil {
+		return "", err
+	}
+	return current, nil
+}
+
+func (f *fakeStore) UpdateUserAvatar(ctx context.Context, arg db.UpdateUserAvatarParams) (*string, error) {
+	previous, ok := f.avatars[arg.ID]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	f.avatars[arg.ID] = arg.AvatarUrl
+	return previous, nil
+}
+
+func (f *fakeStore) GetRelationship(ctx context.Context, arg db.GetRelationshipParams) (db.GetRelationshipRow, error) {
+	return f.relationships[[2]uuid.UUID{arg.ViewerID, arg.UserID}], nil
+}
+
+// newRouter mounts h the way the API does, returning it with the issuer
+// that signs its tokens.
+func newRouter(t *testing.T, h *users.Handler) (http.Handler, *auth.TokenIssuer) {
+	t.Helper()
+	priv, pub, err := auth.GenerateKeyPair(auth.KeyEd25519, 0)
+	require.NoError(t, err)
+	tokens, err := auth.NewTokenIssuer(priv, pub, auth.SystemClock)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.With(middleware.OptionalAuth(tokens)).Get("/users/{username}", h.GetProfile)
+	r.With(middleware.Auth(tokens)).Patch("/users/me/username", h.ChangeUsername)
+	r.With(middleware.Auth(tokens)).Post("/users/me/avatar", h.UploadAvatar)
+	return r, tokens
+}
+
+func get(t *testing.T, h http.Handler, path, token string) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return w, body
+}
+
+func TestGetProfile(t *testing.T) {
+	alice := db.GetUserProfileRow{
+		ID:             uuid.New(),
+		Username:       "alice",
+		DisplayName:    "Alice",
+		CreatedAt:      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		FollowerCount:  3,
+		FollowingCount: 2,
+		PostCount:      7,
+	}
+	bob, carol, mallory := uuid.New(), uuid.New(), uuid.New()
+	store := &fakeStore{
+		profiles: map[string]db.GetUserProfileRow{"alice": alice},
+		blocked: map[[2]uuid.UUID]bool{
+			{alice.ID, mallory}: true,
+			{carol, alice.ID}:   true,
+		},
+		relationships: map[[2]uuid.UUID]db.GetRelationshipRow{
+			{bob, alice.ID}:   {IsFollowing: true},
+			{carol, alice.ID}: {IsBlocked: true},
+		},
+		redirects: map[string]string{"alicia": "alice"},
+	}
+	router, tokens := newRouter(t, users.NewHandler(store, testutil.NewClock(testutil.Epoch), users.Media{}, nil, nil))
+
+	t.Run("Anonymous viewers get counts without flags", func(t *testing.T) {
+		w, body := get(t, router, "/users/alice", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "alice", body["username"])
+		assert.EqualValues(t, 3, body["follower_count"])
+		assert.EqualValues(t, 2, body["following_count"])
+		assert.EqualValues(t, 7, body["post_count"])
+		assert.NotContains(t, body, "is_following")
+		assert.NotContains(t, body, "email")
+		assert.Contains(t, w.Header().Values("Vary"), "Authorization")
+	})
+
+	t.Run("Signed-in viewers get relationship flags", func(t *testing.T) {
+		token, err := tokens.GenerateAccessToken(bob.String())
+		require.NoError(t, err)
+
+		w, body := get(t, router, "/users/alice", token)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, true, body["is_following"])
+		assert.Equal(t, false, body["follows_you"])
+		assert.Equal(t, false, body["is_blocked"])
+	})
+
+	t.Run("Blocked viewers can't find the profile", func(t *testing.T) {
+		token, err := tokens.GenerateAccessToken(mallory.String())
+		require.NoError(t, err)
+
+		w, body := get(t, router, "/users/alice", token)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "user_not_found", body["error"])
+
+		w, _ = get(t, router, "/users/alicia", token)
+		assert.Equal(t, http.StatusNotFound, w.Code, "old usernames don't give the new one away")
+	})
+
+	t.Run("Blockers still see the profile", func(t *testing.T) {
+		token, err := tokens.GenerateAccessToken(carol.String())
+		require.NoError(t, err)
+
+		w, body := get(t, router, "/users/alice", token)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, true, body["is_blocked"])
+	})
+
+	t.Run("Own profile has no flags", func(t *testing.T) {
+		token, err := tokens.GenerateAccessToken(alice.ID.String())
+		require.NoError(t, err)
+
+		w, body := get(t, router, "/users/alice", token)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, body, "is_following")
+	})
+
+	t.Run("Old usernames redirect", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users/alicia", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, "/users/alice", w.Header().Get("Location"))
+	})
+
+	t.Run("Unknown users are 404", func(t *testing.T) {
+		w, body := get(t, router, "/users/nobody", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "user_not_found", body["error"])
+	})
+}
+
+func TestProfileAPI(t *testing.T) {
+	s := testutil.NewServer(t)
+	ctx := context.Background()
+	alice := s.RegisterUser(t, "alice")
+	bob := s.RegisterUser(t, "bob")
+	aliceID, bobID := uuid.MustParse(alice.ID), uuid.MustParse(bob.ID)
+
+	_, err := s.App.Store.CreateFollow(ctx, db.CreateFollowParams{FollowerID: bobID, FollowingID: aliceID})
+	require.NoError(t, err)
+	post, err := s.App.Store.CreatePost(ctx, db.CreatePostParams{UserID: aliceID, Content: "hi", MediaUrls: []string{}})
+	require.NoError(t, err)
+	_, err = s.App.Store.CreatePost(ctx, db.CreatePostParams{UserID: aliceID, Content: "again", MediaUrls: []string{}})
+	require.NoError(t, err)
+	_, err = s.App.Store.SoftDeletePost(ctx, db.SoftDeletePostParams{ID: post.ID, UserID: aliceID})
+	require.NoError(t, err)
+
+	resp := s.Do(t, http.MethodGet, "/api/v1/users/alice", nil, bob)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var body map[string]any
+	testutil.DecodeJSON(t, resp, &body)
+
+	assert.EqualValues(t, 1, body["follower_count"])
+	assert.EqualValues(t, 0, body["following_count"])
+	assert.EqualValues(t, 1, body["post_count"], "soft-deleted posts are not counted")
+	assert.Equal(t, true, body["is_following"])
+	assert.Equal(t, false, body["follows_you"])
+	assert.NotContains(t, body, "email")
+	assert.NotContains(t, body, "password_hash")
+
+	resp = s.Do(t, http.MethodGet, "/api/v1/users/alice", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body = nil
+	testutil.DecodeJSON(t, resp, &body)
+	assert.NotContains(t, body, "is_following")
+}
+
+func TestUpdateProfileAPI(t *testing.T) {
+	s := testutil.NewServer(t)
+	alice := s.RegisterUser(t, "alice")
+
+	resp := s.Do(t, http.MethodPatch, "/api/v1/users/me", map[string]any{
+		"display_name": "Alice A.",
+		"bio":          "Hello\nworld",
+		"pronouns":     "she/her",
+		"links":        []string{"https://alice.example"},
+	}, alice)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var profile users.Profile
+	testutil.DecodeJSON(t, resp, &profile)
+	assert.Equal(t, "Alice A.", profile.DisplayName)
+	assert.Equal(t, "she/her", *profile.Pronouns)
+	assert.Nil(t, profile.Location)
+	assert.Equal(t, []string{"https://alice.example"}, profile.Links)
+
+	resp = s.Do(t, http.MethodPatch, "/api/v1/users/me", map[string]any{"bio": ""}, alice)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	profile = users.Profile{}
+	testutil.DecodeJSON(t, resp, &profile)
+	assert.Nil(t, profile.Bio)
+	assert.Equal(t, "Alice A.", profile.DisplayName, "omitted fields are unchanged")
+
+	resp = s.Do(t, http.MethodPatch, "/api/v1/users/me", map[string]any{"links": []string{"ftp://x"}}, alice)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	body, contentType := multipartFile(t, pngBytes(t, 300, 100))
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/users/me/banner", body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+alice.AccessToken)
+	resp, err = s.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = s.Do(t, http.MethodGet, "/api/v1/users/alice", nil, nil)
+	profile = users.Profile{}
+	testutil.DecodeJSON(t, resp, &profile)
+	require.NotNil(t, profile.BannerURL)
+	assert.Contains(t, *profile.BannerURL, "/avatars/"+alice.ID+"/banner-")
+}
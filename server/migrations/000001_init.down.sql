@@ -0,0 +1 @@
+-- pgcrypto is left installed: other schemas in the database may use it.
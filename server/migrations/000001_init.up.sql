@@ -0,0 +1,3 @@
+-- gen_random_uuid() is built into PostgreSQL 13+; pgcrypto provides it on
+-- older servers and is a no-op otherwise.
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
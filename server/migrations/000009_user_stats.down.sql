@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS trg_user_stats_posts ON posts;
+DROP TRIGGER IF EXISTS trg_user_stats_follows ON follows;
+DROP TRIGGER IF EXISTS trg_user_stats_users ON users;
+DROP FUNCTION IF EXISTS user_stats_posts();
+DROP FUNCTION IF EXISTS user_stats_follows();
+DROP FUNCTION IF EXISTS user_stats_insert_user();
+DROP TABLE IF EXISTS user_stats;
@@ -0,0 +1,71 @@
+-- user_stats holds each user's follower, following and post counts so that
+-- profile reads never run COUNT(*) over follows or posts. Triggers keep it
+-- in step with the source tables inside the writing transaction.
+CREATE TABLE user_stats (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    follower_count BIGINT NOT NULL DEFAULT 0 CHECK (follower_count >= 0),
+    following_count BIGINT NOT NULL DEFAULT 0 CHECK (following_count >= 0),
+    post_count BIGINT NOT NULL DEFAULT 0 CHECK (post_count >= 0)
+);
+
+INSERT INTO user_stats (user_id, follower_count, following_count, post_count)
+SELECT u.id,
+       (SELECT count(*) FROM follows f WHERE f.following_id = u.id),
+       (SELECT count(*) FROM follows f WHERE f.follower_id = u.id),
+       (SELECT count(*) FROM posts p WHERE p.user_id = u.id AND p.deleted_at IS NULL)
+FROM users u;
+
+CREATE FUNCTION user_stats_insert_user() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO user_stats (user_id) VALUES (NEW.id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_user_stats_users
+AFTER INSERT ON users
+FOR EACH ROW EXECUTE FUNCTION user_stats_insert_user();
+
+-- When a user is deleted their follows cascade after the user_stats row is
+-- gone; the UPDATEs below then match nothing, which is what we want.
+CREATE FUNCTION user_stats_follows() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE user_stats SET follower_count = follower_count + 1 WHERE user_id = NEW.following_id;
+        UPDATE user_stats SET following_count = following_count + 1 WHERE user_id = NEW.follower_id;
+    ELSE
+        UPDATE user_stats SET follower_count = follower_count - 1 WHERE user_id = OLD.following_id;
+        UPDATE user_stats SET following_count = following_count - 1 WHERE user_id = OLD.follower_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_user_stats_follows
+AFTER INSERT OR DELETE ON follows
+FOR EACH ROW EXECUTE FUNCTION user_stats_follows();
+
+-- Only live posts count: soft-deleting a post decrements, restoring it
+-- increments again.
+CREATE FUNCTION user_stats_posts() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        IF NEW.deleted_at IS NULL THEN
+            UPDATE user_stats SET post_count = post_count + 1 WHERE user_id = NEW.user_id;
+        END IF;
+    ELSIF TG_OP = 'DELETE' THEN
+        IF OLD.deleted_at IS NULL THEN
+            UPDATE user_stats SET post_count = post_count - 1 WHERE user_id = OLD.user_id;
+        END IF;
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        UPDATE user_stats SET post_count = post_count - 1 WHERE user_id = NEW.user_id;
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        UPDATE user_stats SET post_count = post_count + 1 WHERE user_id = NEW.user_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_user_stats_posts
+AFTER INSERT OR DELETE OR UPDATE OF deleted_at ON posts
+FOR EACH ROW EXECUTE FUNCTION user_stats_posts();
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS username_history;
+DROP INDEX IF EXISTS idx_users_username_key;
+DROP INDEX IF EXISTS idx_users_username_lower;
+CREATE INDEX IF NOT EXISTS idx_users_username ON users (username);
+DROP FUNCTION IF EXISTS username_key(TEXT);
@@ -0,0 +1,44 @@
+-- username_key folds a username to a skeleton shared by every name that
+-- looks like it: case is ignored, 0/o, 1/l/I, rn/m and vv/w collapse, and
+-- the Cyrillic and Greek letters that render like Latin ones map to them.
+-- Two users can never hold names with the same key.
+CREATE FUNCTION username_key(username TEXT) RETURNS TEXT
+    LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE
+AS $$
+    SELECT replace(replace(
+        translate(lower(username), '01iаеорсхуіјԁοα', 'ollaeopcxyljdoa'),
+        'rn', 'm'), 'vv', 'w')
+$$;
+
+-- Names that already collide would fail the unique indexes below. The
+-- oldest account in each collision keeps its name; the others get it cut
+-- to 12 characters plus 8 from a hash of their ID and will want to pick a
+-- new one.
+WITH ranked AS (
+    SELECT id, row_number() OVER (
+        PARTITION BY username_key(username) ORDER BY created_at, id
+    ) AS n
+    FROM users
+)
+UPDATE users u
+SET username = left(u.username, 12) || left(md5(u.id::text), 8), updated_at = NOW()
+FROM ranked r
+WHERE r.id = u.id AND r.n > 1;
+
+DROP INDEX IF EXISTS idx_users_username;
+CREATE UNIQUE INDEX idx_users_username_lower ON users (lower(username));
+CREATE UNIQUE INDEX idx_users_username_key ON users (username_key(username));
+
+-- username_history records every rename. Until released_at the old name
+-- redirects to its owner's current profile and nobody else may claim it.
+CREATE TABLE username_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    username TEXT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL,
+    released_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_username_history_user_id ON username_history (user_id, changed_at DESC);
+CREATE INDEX idx_username_history_lower ON username_history (lower(username));
+CREATE INDEX idx_username_history_key ON username_history (username_key(username));
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS links,
+    DROP COLUMN IF EXISTS pronouns,
+    DROP COLUMN IF EXISTS location,
+    DROP COLUMN IF EXISTS banner_url;
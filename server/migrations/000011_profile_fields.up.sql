@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN banner_url TEXT,
+    ADD COLUMN location TEXT,
+    ADD COLUMN pronouns TEXT,
+    ADD COLUMN links TEXT[] NOT NULL DEFAULT '{}';
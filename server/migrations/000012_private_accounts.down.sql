@@ -0,0 +1,9 @@
+DROP FUNCTION IF EXISTS can_view_posts(UUID, UUID);
+
+DELETE FROM notifications WHERE type = 'follow_request';
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('like', 'comment', 'follow', 'mention'));
+
+DROP TABLE IF EXISTS follow_requests;
+ALTER TABLE users DROP COLUMN IF EXISTS is_private;
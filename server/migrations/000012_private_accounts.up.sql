@@ -0,0 +1,29 @@
+ALTER TABLE users ADD COLUMN is_private BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- follow_requests are follows of private accounts awaiting the target's
+-- approval. Approving one turns it into a row in follows.
+CREATE TABLE follow_requests (
+    requester_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (requester_id, target_id),
+    CONSTRAINT check_self_request CHECK (requester_id != target_id)
+);
+
+CREATE INDEX idx_follow_requests_target_id ON follow_requests (target_id, created_at DESC, requester_id DESC);
+
+ALTER TABLE notifications DROP CONSTRAINT notifications_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('like', 'comment', 'follow', 'mention', 'follow_request'));
+
+-- can_view_posts decides whether viewer may read author's posts and their
+-- comments: public accounts are readable by anyone, private ones only by
+-- their owner and followers. Every query that reads posts filters on it.
+-- viewer is the nil UUID for anonymous requests.
+CREATE FUNCTION can_view_posts(viewer UUID, author UUID) RETURNS BOOLEAN
+    LANGUAGE sql STABLE STRICT PARALLEL SAFE
+AS $$
+    SELECT viewer = author
+        OR NOT (SELECT is_private FROM users WHERE id = author)
+        OR EXISTS (SELECT 1 FROM follows WHERE follower_id = viewer AND following_id = author)
+$$;
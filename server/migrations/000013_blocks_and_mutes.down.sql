@@ -0,0 +1,19 @@
+DROP TRIGGER IF EXISTS trg_reject_blocked_notifications ON notifications;
+DROP TRIGGER IF EXISTS trg_reject_blocked_comments ON comments;
+DROP TRIGGER IF EXISTS trg_reject_blocked_likes ON likes;
+DROP TRIGGER IF EXISTS trg_reject_blocked_follow_requests ON follow_requests;
+DROP TRIGGER IF EXISTS trg_reject_blocked_follows ON follows;
+DROP FUNCTION IF EXISTS reject_blocked();
+
+CREATE OR REPLACE FUNCTION can_view_posts(viewer UUID, author UUID) RETURNS BOOLEAN
+    LANGUAGE sql STABLE STRICT PARALLEL SAFE
+AS $$
+    SELECT viewer = author
+        OR NOT (SELECT is_private FROM users WHERE id = author)
+        OR EXISTS (SELECT 1 FROM follows WHERE follower_id = viewer AND following_id = author)
+$$;
+
+DROP FUNCTION IF EXISTS visible_to(UUID, UUID, TIMESTAMPTZ);
+DROP FUNCTION IF EXISTS blocked_between(UUID, UUID);
+DROP TABLE IF EXISTS mutes;
+DROP TABLE IF EXISTS blocks;
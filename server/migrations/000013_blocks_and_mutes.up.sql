@@ -0,0 +1,113 @@
+-- blocks cut two users off from each other in both directions.
+CREATE TABLE blocks (
+    blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (blocker_id, blocked_id),
+    CONSTRAINT check_self_block CHECK (blocker_id != blocked_id)
+);
+
+CREATE INDEX idx_blocks_blocked_id ON blocks (blocked_id);
+
+-- mutes hide the muted account from the muter's feed and notifications
+-- without telling anyone. A NULL expires_at mutes indefinitely.
+CREATE TABLE mutes (
+    muter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    PRIMARY KEY (muter_id, muted_id),
+    CONSTRAINT check_self_mute CHECK (muter_id != muted_id)
+);
+
+-- blocked_between reports whether either user blocks the other.
+CREATE FUNCTION blocked_between(a UUID, b UUID) RETURNS BOOLEAN
+    LANGUAGE sql STABLE STRICT PARALLEL SAFE
+AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocker_id = a AND blocked_id = b) OR (blocker_id = b AND blocked_id = a)
+    )
+$$;
+
+-- visible_to is the filter every read of user content goes through: it
+-- hides author from viewer if either blocks the other and, unless mutes_at
+-- is NULL, if viewer has muted author as of mutes_at. Mutes only apply to
+-- the feed and notifications, so other reads pass NULL.
+CREATE FUNCTION visible_to(viewer UUID, author UUID, mutes_at TIMESTAMPTZ) RETURNS BOOLEAN
+    LANGUAGE sql STABLE PARALLEL SAFE
+AS $$
+    SELECT viewer = author OR NOT (
+        blocked_between(viewer, author)
+        OR (mutes_at IS NOT NULL AND EXISTS (
+            SELECT 1 FROM mutes
+            WHERE muter_id = viewer AND muted_id = author
+              AND (expires_at IS NULL OR expires_at > mutes_at)
+        ))
+    )
+$$;
+
+-- can_view_posts adds privacy to visible_to: private accounts are only
+-- readable by their owner and followers.
+CREATE OR REPLACE FUNCTION can_view_posts(viewer UUID, author UUID) RETURNS BOOLEAN
+    LANGUAGE sql STABLE STRICT PARALLEL SAFE
+AS $$
+    SELECT viewer = author
+        OR (visible_to(viewer, author, NULL)
+            AND (NOT (SELECT is_private FROM users WHERE id = author)
+                 OR EXISTS (SELECT 1 FROM follows WHERE follower_id = viewer AND following_id = author)))
+$$;
+
+-- reject_blocked refuses follows, follow requests, likes, comments and
+-- notifications (which covers mentions) between users who block each
+-- other. It raises a check_violation of the "blocked" constraint.
+CREATE FUNCTION reject_blocked() RETURNS TRIGGER
+    LANGUAGE plpgsql
+AS $$
+DECLARE
+    actor UUID;
+    targets UUID[];
+    target UUID;
+BEGIN
+    CASE TG_TABLE_NAME
+    WHEN 'follows' THEN
+        actor := NEW.follower_id;
+        targets := ARRAY[NEW.following_id];
+    WHEN 'follow_requests' THEN
+        actor := NEW.requester_id;
+        targets := ARRAY[NEW.target_id];
+    WHEN 'likes' THEN
+        actor := NEW.user_id;
+        targets := ARRAY(SELECT user_id FROM posts WHERE id = NEW.post_id);
+    WHEN 'comments' THEN
+        actor := NEW.user_id;
+        targets := ARRAY(
+            SELECT user_id FROM posts WHERE id = NEW.post_id
+            UNION
+            SELECT user_id FROM comments WHERE id = NEW.parent_id
+        );
+    WHEN 'notifications' THEN
+        actor := NEW.actor_id;
+        targets := ARRAY[NEW.recipient_id];
+    END CASE;
+
+    FOREACH target IN ARRAY targets LOOP
+        IF blocked_between(actor, target) THEN
+            RAISE EXCEPTION 'user % and user % have a block between them', actor, target
+                USING ERRCODE = 'check_violation', CONSTRAINT = 'blocked', TABLE = TG_TABLE_NAME;
+        END IF;
+    END LOOP;
+    RETURN NEW;
+END
+$$;
+
+CREATE TRIGGER trg_reject_blocked_follows BEFORE INSERT ON follows
+    FOR EACH ROW EXECUTE FUNCTION reject_blocked();
+CREATE TRIGGER trg_reject_blocked_follow_requests BEFORE INSERT ON follow_requests
+    FOR EACH ROW EXECUTE FUNCTION reject_blocked();
+CREATE TRIGGER trg_reject_blocked_likes BEFORE INSERT ON likes
+    FOR EACH ROW EXECUTE FUNCTION reject_blocked();
+CREATE TRIGGER trg_reject_blocked_comments BEFORE INSERT ON comments
+    FOR EACH ROW EXECUTE FUNCTION reject_blocked();
+CREATE TRIGGER trg_reject_blocked_notifications BEFORE INSERT ON notifications
+    FOR EACH ROW EXECUTE FUNCTION reject_blocked();
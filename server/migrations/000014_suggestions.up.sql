@@ -0,0 +1,3 @@
+-- Suggestions fall back to the most-followed accounts for users with no
+-- graph to go on.
+CREATE INDEX idx_user_stats_follower_count ON user_stats (follower_count DESC);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_display_name_trgm;
+DROP INDEX IF EXISTS idx_users_username_trgm;
+DROP FUNCTION IF EXISTS search_fold(TEXT);
+-- pg_trgm and unaccent are left installed: other schemas in the database
+-- may use them.
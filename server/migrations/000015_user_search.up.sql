@@ -0,0 +1,17 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm SCHEMA public;
+CREATE EXTENSION IF NOT EXISTS unaccent SCHEMA public;
+
+-- search_fold is what user search matches on: lower case with accents
+-- stripped, so "José" is found by "jose". unaccent() is only STABLE since
+-- its dictionary could change, so the dictionary is named explicitly here
+-- to make the fold indexable, and schema-qualified because index
+-- maintenance runs with an empty search_path.
+CREATE FUNCTION search_fold(s TEXT) RETURNS TEXT
+    LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE
+AS $$
+    SELECT lower(public.unaccent('public.unaccent'::regdictionary, s))
+$$;
+
+-- Trigram indexes serve both fuzzy (%) and prefix (LIKE 'abc%') matches.
+CREATE INDEX idx_users_username_trgm ON users USING gin (search_fold(username) gin_trgm_ops);
+CREATE INDEX idx_users_display_name_trgm ON users USING gin (search_fold(display_name) gin_trgm_ops);
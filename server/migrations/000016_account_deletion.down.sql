@@ -0,0 +1,26 @@
+CREATE OR REPLACE FUNCTION visible_to(viewer UUID, author UUID, mutes_at TIMESTAMPTZ) RETURNS BOOLEAN
+    LANGUAGE sql STABLE PARALLEL SAFE
+AS $$
+    SELECT viewer = author OR NOT (
+        blocked_between(viewer, author)
+        OR (mutes_at IS NOT NULL AND EXISTS (
+            SELECT 1 FROM mutes
+            WHERE muter_id = viewer AND muted_id = author
+              AND (expires_at IS NULL OR expires_at > mutes_at)
+        ))
+    )
+$$;
+
+DROP FUNCTION IF EXISTS account_active(UUID);
+
+DELETE FROM comments WHERE user_id IS NULL;
+ALTER TABLE comments DROP CONSTRAINT IF EXISTS comments_user_id_fkey;
+ALTER TABLE comments ADD CONSTRAINT comments_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE comments ALTER COLUMN user_id SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_users_deletion_scheduled_at;
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS check_deletion_deactivated,
+    DROP COLUMN IF EXISTS deletion_scheduled_at,
+    DROP COLUMN IF EXISTS deactivated_at;
@@ -0,0 +1,40 @@
+-- A deactivated account is hidden from everyone but can be restored. One
+-- scheduled for deletion is deactivated too, and is purged once
+-- deletion_scheduled_at passes unless restored first.
+ALTER TABLE users
+    ADD COLUMN deactivated_at TIMESTAMPTZ,
+    ADD COLUMN deletion_scheduled_at TIMESTAMPTZ,
+    ADD CONSTRAINT check_deletion_deactivated
+        CHECK (deletion_scheduled_at IS NULL OR deactivated_at IS NOT NULL);
+
+CREATE INDEX idx_users_deletion_scheduled_at ON users (deletion_scheduled_at, id)
+    WHERE deletion_scheduled_at IS NOT NULL;
+
+-- Comments with replies outlive their author as tombstones, so purging an
+-- account leaves its place in other people's threads.
+ALTER TABLE comments ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE comments DROP CONSTRAINT comments_user_id_fkey;
+ALTER TABLE comments ADD CONSTRAINT comments_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+-- account_active reports whether account exists and is not deactivated.
+CREATE FUNCTION account_active(account UUID) RETURNS BOOLEAN
+    LANGUAGE sql STABLE PARALLEL SAFE
+AS $$
+    SELECT EXISTS (SELECT 1 FROM users WHERE id = account AND deactivated_at IS NULL)
+$$;
+
+-- visible_to now also hides deactivated authors from everyone but
+-- themselves.
+CREATE OR REPLACE FUNCTION visible_to(viewer UUID, author UUID, mutes_at TIMESTAMPTZ) RETURNS BOOLEAN
+    LANGUAGE sql STABLE PARALLEL SAFE
+AS $$
+    SELECT viewer = author OR (account_active(author) AND NOT (
+        blocked_between(viewer, author)
+        OR (mutes_at IS NOT NULL AND EXISTS (
+            SELECT 1 FROM mutes
+            WHERE muter_id = viewer AND muted_id = author
+              AND (expires_at IS NULL OR expires_at > mutes_at)
+        ))
+    ))
+$$;
@@ -0,0 +1,11 @@
+// Package migrations embeds the SQL migrations so binaries can apply them
+// without the files on disk. The files follow golang-migrate's naming,
+// NNNNNN_name.up.sql and NNNNNN_name.down.sql.
+package migrations
+
+import "embed"
+
+// FS holds every migration file.
+//
+//go:embed *.sql
+var FS embed.FS